@@ -0,0 +1,19 @@
+package api
+
+type AlertRule struct {
+	Position      Position
+	Recipient     string
+	Events        []string
+	ExcludeEvents bool // alert on every event except Events
+	Reminder      int  // in cycles
+	MailFormat    MailFormat
+	NoAlert       bool // the recipient gets no alerts for the check
+}
+
+type MailFormat struct {
+	Position Position
+	From     string
+	ReplyTo  string
+	Subject  string
+	Message  string
+}
@@ -1,53 +1,324 @@
 package api
 
+// Position locates a check or test in its monitrc file.
+type Position struct {
+	File   string // the file the check or test was read from, when known.
+	Line   int
+	Column int
+}
+
 type ProcessCheck struct {
+	Position       Position
 	Name           string
+	Comments       []string // the comment lines just above the check statement
 	Pidfile        string
+	Matching       string // regex matched against the process command line, in place of a pidfile
 	StartProgram   CheckProgram
 	StopProgram    CheckProgram
-	FailedSocket   FailedSocket
-	FailedHost     FailedHost
+	FailedSockets  []FailedSocket
+	FailedHosts    []FailedHost
 	TotalMemChecks []MemUsage
-	Group          string
-	DependsOn      string
+	CpuChecks      []CpuUsage
+	MemoryChecks   []MemUsage
+	Alerts         []AlertRule
+	Groups         []string
+	DependsOn      []string
 }
 
-
 type CheckProgram struct {
-	Path string
-	Uid string
-	Gid string
+	Position Position
+	Path     string
+	Uid      string
+	Gid      string
+	Timeout  int
 }
 
 type FileCheck struct {
-	Name           string
-	Path           string
-	IfChanged      string
-	FailedSocket   FailedSocket
-	FailedHost     FailedHost
-	TotalMemChecks []MemUsage
-	Group          string
-	DependsOn      string
+	Position        Position
+	Name            string
+	Comments        []string
+	Path            string
+	IfChangedChecks []IfChanged
+	StartProgram    CheckProgram
+	StopProgram     CheckProgram
+	FailedSockets   []FailedSocket
+	FailedHosts     []FailedHost
+	TotalMemChecks  []MemUsage
+	Alerts          []AlertRule
+	Groups          []string
+	DependsOn       []string
+}
+
+type FilesystemCheck struct {
+	Position         Position
+	Name             string
+	Comments         []string
+	Path             string
+	StartProgram     CheckProgram
+	StopProgram      CheckProgram
+	SpaceUsageChecks []DiskUsage
+	SpaceFreeChecks  []DiskUsage
+	InodeUsageChecks []DiskUsage
+	InodeFreeChecks  []DiskUsage
+	ReadRateChecks   []IORate
+	WriteRateChecks  []IORate
+	Permission       Permission
+	IfChangedChecks  []IfChanged
+	Alerts           []AlertRule
+	Groups           []string
+	DependsOn        []string
+}
+
+type DirectoryCheck struct {
+	Position        Position
+	Name            string
+	Comments        []string
+	Path            string
+	StartProgram    CheckProgram
+	StopProgram     CheckProgram
+	Permission      Permission
+	Uid             Owner
+	Gid             Owner
+	TimestampChecks []Timestamp
+	IfChangedChecks []IfChanged
+	Alerts          []AlertRule
+	Groups          []string
+	DependsOn       []string
+}
+
+type FifoCheck struct {
+	Position        Position
+	Name            string
+	Comments        []string
+	Path            string
+	StartProgram    CheckProgram
+	StopProgram     CheckProgram
+	Permission      Permission
+	Uid             Owner
+	Gid             Owner
+	TimestampChecks []Timestamp
+	IfChangedChecks []IfChanged
+	Alerts          []AlertRule
+	Groups          []string
+	DependsOn       []string
+}
+
+type ProgramCheck struct {
+	Position        Position
+	Name            string
+	Comments        []string
+	Path            string
+	Timeout         int // in seconds
+	StartProgram    CheckProgram
+	StopProgram     CheckProgram
+	StatusChecks    []ProgramStatus
+	IfChangedChecks []IfChanged
+	Alerts          []AlertRule
+	Groups          []string
+	DependsOn       []string
+}
+
+type NetworkCheck struct {
+	Position            Position
+	Name                string
+	Comments            []string
+	Interface           string
+	Address             string
+	StartProgram        CheckProgram
+	StopProgram         CheckProgram
+	FailedLinks         []FailedLink
+	SaturationChecks    []Saturation
+	UploadChecks        []Bandwidth
+	DownloadChecks      []Bandwidth
+	TotalUploadChecks   []Bandwidth
+	TotalDownloadChecks []Bandwidth
+	IfChangedChecks     []IfChanged
+	Alerts              []AlertRule
+	Groups              []string
+	DependsOn           []string
+}
+
+type HostCheck struct {
+	Position     Position
+	Name         string
+	Comments     []string
+	Address      string
+	StartProgram CheckProgram
+	StopProgram  CheckProgram
+	FailedHosts  []FailedHost
+	FailedPings  []FailedPing
+	Alerts       []AlertRule
+	Groups       []string
+	DependsOn    []string
+}
+
+type SystemCheck struct {
+	Position      Position
+	Name          string
+	Comments      []string
+	LoadAvgChecks []LoadAvg
+	CpuChecks     []CpuUsage
+	MemoryChecks  []MemUsage
+	SwapChecks    []MemUsage
+	UptimeChecks  []Uptime
+	Alerts        []AlertRule
+	Groups        []string
+	DependsOn     []string
+}
+
+type IfChanged struct {
+	Position  Position
+	Attribute string
+	Action    string
+	Exec      string // the command of an exec action
 }
 
 type FailedSocket struct {
+	Position   Position
 	SocketFile string
 	Timeout    int
 	NumCycles  int
 	Action     string
+	Exec       string
 }
 
 type FailedHost struct {
+	Position  Position
 	Host      string
-	Port      string
+	Port      int
+	Type      string
 	Protocol  string
 	Timeout   int
 	NumCycles int
 	Action    string
+	Exec      string
 }
 
 type MemUsage struct {
-	MemLimit  int
+	Position     Position
+	Operator     string
+	MemLimit     int64   // in bytes
+	PercentLimit float64 // set instead of MemLimit when IsPercent
+	IsPercent    bool
+	NumCycles    int
+	Action       string
+	Exec         string
+}
+
+type FailedPing struct {
+	Position  Position
+	Count     int
+	Timeout   int
+	NumCycles int
+	Action    string
+	Exec      string
+}
+
+type LoadAvg struct {
+	Position  Position
+	Period    string // 1min, 5min or 15min
+	Operator  string
+	Limit     float64
+	NumCycles int
+	Action    string
+	Exec      string
+}
+
+type CpuUsage struct {
+	Position     Position
+	Kind         string // user, system or wait; empty for the total usage
+	Operator     string
+	PercentLimit float64
+	NumCycles    int
+	Action       string
+	Exec         string
+}
+
+type Uptime struct {
+	Position  Position
+	Operator  string
+	Seconds   int64
+	NumCycles int
+	Action    string
+	Exec      string
+}
+
+type DiskUsage struct {
+	Position     Position
+	Operator     string
+	Limit        int64 // in bytes for space tests, in inodes for inode tests
+	PercentLimit float64
+	IsPercent    bool
+	NumCycles    int
+	Action       string
+	Exec         string
+}
+
+type IORate struct {
+	Position     Position
+	Operator     string
+	Limit        int64 // in bytes or operations per second
+	IsOperations bool
+	NumCycles    int
+	Action       string
+	Exec         string
+}
+
+type Permission struct {
+	Position Position
+	Mode     string // octal, e.g. 0755
+	Action   string
+	Exec     string
+}
+
+type Owner struct {
+	Position Position
+	Name     string // user or group name, or numeric id
+	Action   string
+	Exec     string
+}
+
+type Timestamp struct {
+	Position  Position
+	Operator  string
+	Seconds   int64
 	NumCycles int
 	Action    string
-}
\ No newline at end of file
+	Exec      string
+}
+
+type ProgramStatus struct {
+	Position  Position
+	Operator  string
+	Status    int
+	NumCycles int
+	Action    string
+	Exec      string
+}
+
+type FailedLink struct {
+	Position  Position
+	NumCycles int
+	Action    string
+	Exec      string
+}
+
+type Saturation struct {
+	Position     Position
+	Operator     string
+	PercentLimit float64
+	NumCycles    int
+	Action       string
+	Exec         string
+}
+
+type Bandwidth struct {
+	Position  Position
+	Operator  string
+	Limit     int64 // in bytes, or packets if IsPackets
+	IsPackets bool
+	Period    int64 // in seconds; 1 for a rate per second, the window of a total otherwise
+	NumCycles int
+	Action    string
+	Exec      string
+}
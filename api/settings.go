@@ -0,0 +1,96 @@
+package api
+
+type Settings struct {
+	Daemon         Daemon
+	Logfile        string
+	SyslogFacility string
+	Pidfile        string
+	Idfile         string
+	Statefile      string
+	Limits         Limits
+	OnReboot       string // start, nostart or laststate
+	HTTPD          HTTPDSettings
+	Mailservers    MailserverSettings
+	Alerts         []AlertRule
+	MailFormat     MailFormat
+	MMonit         []MMonitSettings
+	EventQueue     EventQueue
+}
+
+type Daemon struct {
+	Position   Position
+	Interval   int // in seconds
+	StartDelay int // in seconds
+}
+
+type Limits struct {
+	Position          Position
+	ProgramOutput     int64 // in bytes
+	SendExpectBuffer  int64 // in bytes
+	FileContentBuffer int64 // in bytes
+	HttpContentBuffer int64 // in bytes
+	NetworkTimeout    int64 // in seconds
+	ProgramTimeout    int64 // in seconds
+	StopTimeout       int64 // in seconds
+	StartTimeout      int64 // in seconds
+	RestartTimeout    int64 // in seconds
+}
+
+type MailserverSettings struct {
+	Position Position
+	Servers  []Mailserver
+	Timeout  int // in seconds
+}
+
+type Mailserver struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Using    string // ssl, tls or a version such as tlsv12; empty for a cleartext connection
+}
+
+type HTTPDSettings struct {
+	Position    Position
+	Port        int
+	Address     string
+	AllowHosts  []string // host names, IP addresses and networks
+	AllowGroups []string
+	AllowUsers  []HTTPDUser
+	SSL         SSLOptions
+}
+
+type HTTPDUser struct {
+	Name     string
+	Password string
+	ReadOnly bool
+}
+
+type SSLOptions struct {
+	Enabled         bool
+	PemFile         string
+	ClientPemFile   string
+	AllowSelfSigned bool
+}
+
+type MMonitSettings struct {
+	Position                   Position
+	URL                        string
+	Host                       string // host and port of the collector
+	Username                   string
+	Password                   string
+	Timeout                    int // in seconds
+	RegisterWithoutCredentials bool
+}
+
+type EventQueue struct {
+	Position Position
+	Basedir  string
+	Slots    int
+}
+
+// Include is an include statement, naming the files to read in place of it.
+type Include struct {
+	Position Position
+	Pattern  string // a glob, as understood by path.Match.
+}
@@ -0,0 +1,16 @@
+package lex
+
+/*
+INCLUDE <GLOB>
+ */
+func IncludeStart(l *lexer) stateFn {
+	l.emitKeyword("include", itemInclude)
+
+	if next := l.peek(); isEndOfLine(next) || isEof(next) {
+		return l.errorf("include missing file pattern")
+	}
+	if err := emitStringValue(l); err != nil {
+		return l.errorf("%s", err)
+	}
+	return ServiceCheckStart
+}
@@ -1,6 +1,7 @@
 package lex
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"unicode"
@@ -13,8 +14,13 @@ type Pos int
 // Item represents a token returned from the scanner.
 // Item represents a token or text string returned from the scanner.
 type Item struct {
-	Type  itemType // The type of this Item.
-	Value string   // The value of this Item.
+	Type   itemType // The type of this Item.
+	Value  string   // The value of this Item.
+	Pos    Pos      // The starting position, in bytes, of this Item in the input.
+	Line   int      // The 1-based line of Pos.
+	Column int      // The 1-based column, in runes, of Pos.
+	err    error    // The error reported by an itemError Item.
+	lexer  *lexer   // The lexer that scanned this Item, for error reports.
 }
 
 const (
@@ -26,34 +32,71 @@ const (
 	itemError       itemType = iota // error occurred;
 	itemEOF
 	itemStringValue
+	itemComment // from '#' to the end of the line
+	itemNumber  // an unquoted integer or decimal number
 
 	itemCheckStart
 
 	itemCheckProcess
 	itemCheckFile
+	itemCheckFilesystem
+	itemCheckDirectory
+	itemCheckFifo
+	itemCheckProgram
+	itemCheckNetwork
+	itemCheckHost
+	itemCheckSystem
 
 	itemServiceDependencies
 	itemInsideCheckResourceTesting
 	itemInsideCheckResourceTestingOperator
+	itemInsideCheckResourceTestingQualifier
+	itemInsideCheckResourceTestingUnit
+	itemInsideCheckResourceTestingPeriod
+	itemInsideCheckResourceTesting_LoadAvg
+	itemInsideCheckResourceTesting_Cpu
+	itemInsideCheckResourceTesting_Memory
+	itemInsideCheckResourceTesting_Swap
+	itemInsideCheckResourceTesting_Uptime
+	itemInsideCheckResourceTesting_SpaceUsage
+	itemInsideCheckResourceTesting_SpaceFree
+	itemInsideCheckResourceTesting_InodeUsage
+	itemInsideCheckResourceTesting_InodeFree
+	itemInsideCheckResourceTesting_ReadRate
+	itemInsideCheckResourceTesting_WriteRate
+	itemInsideCheckResourceTesting_Timestamp
+	itemInsideCheckResourceTesting_Status
+	itemInsideCheckResourceTesting_Saturation
+	itemInsideCheckResourceTesting_Upload
+	itemInsideCheckResourceTesting_Download
+	itemInsideCheckResourceTesting_TotalUpload
+	itemInsideCheckResourceTesting_TotalDownload
 
 	itemInsideCheckProcess_Name
 	itemInsideCheckProcess_Pid
+	itemInsideCheckProcess_Matching
 	itemInsideCheckProcess_ProgramMethodQuotedStringValue
 	itemInsideCheckProcess_ProgramMethodUnQuotedStringValue
 
 	itemInsideCheckProcess_StartProgramMethod
 	itemInsideCheckProcess_ProgramMethodUid
 	itemInsideCheckProcess_ProgramMethodGid
+	itemInsideCheckProcess_ProgramMethodTimeout
 	itemInsideCheckProcess_ProgramMethodGroupName
 
 	itemInsideCheckProcess_StopProgramMethod
-	itemInsideCheckProcess_ProgramMethodPath
+
+	itemInsideCheckProcess_Alert
+	itemInsideCheckProcess_NoAlert
 
 	itemInsideCheckProcess_ConnectionTestingEnterIfConditions
 	itemInsideCheckProcess_ConnectionTesting_UnixSocket
 	itemInsideCheckProcess_ConnectionTesting_TcpUdpHost
 	itemInsideCheckProcess_ConnectionTesting_TcpUdpPort
 	itemInsideCheckProcess_ConnectionTesting_TcpUdpProtocol
+	itemInsideCheckProcess_ConnectionTesting_TcpUdpType
+	itemInsideCheckProcess_ConnectionTesting_Ping
+	itemInsideCheckProcess_ConnectionTesting_Count
 
 	itemInsideCheckProcess_ConnectionTesting_Timeout
 	itemInsideCheckProcess_ConnectionTesting_Cycle
@@ -62,6 +105,66 @@ const (
 
 	itemInsideCheckFile_Name
 	itemInsideCheckFile_Path
+	itemInsideCheckFile_IfChanged
+	itemInsideCheckFile_Permission
+	itemInsideCheckFile_Uid
+	itemInsideCheckFile_Gid
+
+	itemInsideCheckFilesystem_Name
+	itemInsideCheckDirectory_Name
+	itemInsideCheckFifo_Name
+
+	itemInclude
+
+	itemSetStart
+	itemSet_Daemon
+	itemSet_StartDelay
+	itemSet_Logfile
+	itemSet_Facility
+	itemSet_Pidfile
+	itemSet_Idfile
+	itemSet_Statefile
+	itemSet_Limits
+	itemSet_OptionName
+	itemSet_OnReboot
+	itemSet_HTTPD
+	itemSet_Mailserver
+	itemSet_Alert
+	itemSet_MailFormat
+	itemSet_Port
+	itemSet_Username
+	itemSet_Password
+	itemSet_Using
+	itemSet_Timeout
+	itemSet_Reminder
+	itemSet_On
+	itemSet_NotOn
+	itemSet_MMonit
+	itemSet_RegisterWithoutCredentials
+	itemSet_EventQueue
+	itemSet_Basedir
+	itemSet_Slots
+	itemSetHTTPD_Address
+	itemSetHTTPD_Allow
+	itemSetHTTPD_ReadOnly
+	itemSetHTTPD_SSL
+	itemSetHTTPD_PemFile
+
+	itemBlockStart
+	itemBlockEnd
+	itemComma
+
+	itemInsideCheckProgram_Name
+	itemInsideCheckProgram_Path
+
+	itemInsideCheckNetwork_Name
+	itemInsideCheckNetwork_Interface
+	itemInsideCheckNetwork_Link
+
+	itemInsideCheckHost_Name
+	itemInsideCheckHost_Address
+
+	itemInsideCheckSystem_Name
 )
 
 func (i Item) String() string {
@@ -77,38 +180,109 @@ func (i Item) String() string {
 	return fmt.Sprintf("%q", i.Value)
 }
 
+// errorf returns a ParseError located at the item, naming the file given to Lex.
+func (i Item) errorf(format string, args ...interface{}) *ParseError {
+	err := &ParseError{
+		Line:    i.Line,
+		Column:  i.Column,
+		Snippet: i.Value,
+		Msg:     fmt.Sprintf(format, args...),
+	}
+	if i.lexer != nil {
+		err.File = i.lexer.name
+		err.Snippet = i.lexer.input[i.Pos:]
+		if end := strings.IndexAny(err.Snippet, "\r\n"); end >= 0 {
+			err.Snippet = err.Snippet[:end]
+		}
+	}
+	return err
+}
+
 // stateFn represents the state of the scanner
 // as a function that returns the next state.
 type stateFn func(*lexer) stateFn
 
 type lexer struct {
-	name  string    // used only for error reports.
-	input string    // the string being scanned.
-	start int       // start position of this Item.
-	pos   int       // current position in the input.
-	width int       // width of last rune read from input.
-	items chan Item // channel of scanned items.
+	name      string          // used only for error reports.
+	input     string          // the string being scanned.
+	start     int             // start position of this Item.
+	pos       int             // current position in the input.
+	width     int             // width of last rune read from input.
+	items     chan Item       // channel of scanned items.
+	linePos   int             // position up to which lines have been counted.
+	lines     int             // number of newlines before linePos.
+	lineStart int             // position of the first rune of the line containing linePos.
+	inCheck   bool            // whether the statements being scanned belong to a check.
+	ctx       context.Context // done when the consumer stops reading items.
 }
 
+// Lex scans input in a goroutine, delivering its items on the returned channel.
+// The channel must be read until it is closed; use LexContext to stop reading early.
 func Lex(name, input string) (*lexer, chan Item) {
+	return LexContext(context.Background(), name, input)
+}
+
+// LexContext is like Lex, but stops scanning once ctx is done, so that a consumer giving up
+// on the items does not leave the goroutine blocked. The items then end with an itemError
+// carrying ctx.Err(), which Parse returns rather than the tree of a truncated input.
+func LexContext(ctx context.Context, name, input string) (*lexer, chan Item) {
 	l := &lexer{
 		name:  name,
 		input: input,
-		items: make(chan Item),
+		items: make(chan Item, 1), // room for the itemError of stop.
+		ctx:   ctx,
 	}
 	go l.run() // Concurrently run state machine.
 	return l, l.items
 }
 
 // run lexes the input by executing state functions until
-// the state is nil.
+// the state is nil or the consumer is done.
 func (l *lexer) run() {
-	for state := ServiceCheckStart; state != nil; {
+	for state := ServiceCheckStart; state != nil && !l.cancelled(); {
 		state = state(l)
 	}
+	if l.cancelled() {
+		l.stop()
+		return
+	}
 	close(l.items) // No more tokens will be delivered.
 }
 
+// stop ends the items of a cancelled scan with an itemError carrying the error of the context,
+// in place of any item the consumer has not read, and closes the channel.
+// It does not block, whether or not the consumer still reads the items.
+func (l *lexer) stop() {
+	line, column := l.lineColumn(l.start)
+	item := Item{Type: itemError, Value: l.ctx.Err().Error(), Pos: Pos(l.start), Line: line, Column: column, err: l.ctx.Err(), lexer: l}
+	for {
+		select {
+		case l.items <- item:
+			close(l.items)
+			return
+		case <-l.items:
+		}
+	}
+}
+
+// cancelled reports whether the consumer is done with the items.
+func (l *lexer) cancelled() bool {
+	select {
+	case <-l.ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// send delivers item, unless the consumer is done with the items.
+func (l *lexer) send(item Item) {
+	select {
+	case l.items <- item:
+	case <-l.ctx.Done():
+	}
+}
+
 // next returns the next rune in the input.
 func (l *lexer) next() (rune rune) {
 	if l.pos >= len(l.input) {
@@ -134,10 +308,19 @@ func (l *lexer) current() (rune rune) {
 
 // emit passes an Item back to the client.
 func (l *lexer) emit(t itemType) {
-	l.items <- Item{t, l.input[l.start:l.pos]}
+	line, column := l.lineColumn(l.start)
+	l.send(Item{Type: t, Value: l.input[l.start:l.pos], Pos: Pos(l.start), Line: line, Column: column, lexer: l})
 	l.start = l.pos
 }
 
+// emitEOF passes the itemEOF ending the items back to the client, at the end of the input.
+func (l *lexer) emitEOF() stateFn {
+	l.pos = len(l.input)
+	l.ignore()
+	l.emit(itemEOF)
+	return nil
+}
+
 func (l *lexer) ignore() {
 	l.start = l.pos
 }
@@ -145,10 +328,31 @@ func (l *lexer) ignore() {
 // errorf returns an error token and terminates the scan by passing
 // back a nil pointer that will be the next state, terminating l.nextItem.
 func (l *lexer) errorf(format string, args ...interface{}) stateFn {
-	l.items <- Item{itemError, fmt.Sprintf(format, args...)}
+	message := fmt.Sprintf(format, args...)
+	line, column := l.lineColumn(l.start)
+	item := Item{Type: itemError, Value: message, Pos: Pos(l.start), Line: line, Column: column, lexer: l}
+	item.err = item.errorf("%s", message)
+
+	l.send(item)
 	return nil
 }
 
+// lineColumn returns the 1-based line and column of the byte offset pos in the input.
+// Lines are counted incrementally, as items are emitted in input order.
+func (l *lexer) lineColumn(pos int) (line, column int) {
+	if pos < l.linePos {
+		l.linePos, l.lines, l.lineStart = 0, 0, 0
+	}
+	scanned := l.input[l.linePos:pos]
+	l.lines += strings.Count(scanned, "\n")
+	if i := strings.LastIndex(scanned, "\n"); i >= 0 {
+		l.lineStart = l.linePos + i + 1
+	}
+	l.linePos = pos
+
+	return l.lines + 1, 1 + utf8.RuneCountInString(l.input[l.lineStart:pos])
+}
+
 // leftTrimLength returns the length of the spaces at the beginning of the string.
 func leftTrimLength(s string) int {
 	return len(s) - len(strings.TrimLeft(s, spaceChars))
@@ -171,6 +375,105 @@ func (l *lexer) acceptRun(valid string) {
 	l.backup()
 }
 
+// hasKeyword reports whether the input continues with one of the keywords.
+func (l *lexer) hasKeyword(keywords ...string) bool {
+	for _, keyword := range keywords {
+		if _, end := scanKeyword(l.input[l.pos:], keyword); end > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptKeyword consumes the keyword if the input continues with it.
+func (l *lexer) acceptKeyword(keyword string) bool {
+	_, end := scanKeyword(l.input[l.pos:], keyword)
+	l.pos += end
+	return end > 0
+}
+
+// emitKeyword consumes the keyword, if the input continues with it, and emits it as an item of type t.
+// The noise keywords before it are left out of the item.
+func (l *lexer) emitKeyword(keyword string, t itemType) bool {
+	start, end := scanKeyword(l.input[l.pos:], keyword)
+	if end == 0 {
+		return false
+	}
+	l.pos += start
+	l.ignore()
+	l.pos += end - start
+	l.emit(t)
+	l.skipWhiteSpaces()
+	return true
+}
+
+// scanKeyword returns where the keyword at the start of input starts and ends, or 0, 0 if input does not start with it.
+// Keywords match whole words only, in any case, so "start" is read from "START" but not from "startup_delay",
+// and the words of a keyword such as "if failed" may be separated by any run of spaces.
+// Like monit, scanKeyword skips the noise keywords of input and treats those of keyword as optional,
+// so "port" matches "on port" and "with timeout" matches "timeout". A keyword made only of noise
+// keywords, such as "then", must be matched word for word.
+func scanKeyword(input string, keyword string) (start, end int) {
+	words := strings.Fields(keyword)
+	literal := true
+	for _, word := range words {
+		literal = literal && isNoiseKeyword(word)
+	}
+
+	pos := 0
+	for _, word := range words {
+		for {
+			next, after := nextWord(input, pos)
+			if strings.EqualFold(next, word) {
+				if end == 0 {
+					start = after - len(next)
+				}
+				pos, end = after, after
+				break
+			}
+			if literal || !isNoiseKeyword(word) && !isNoiseKeyword(next) {
+				return 0, 0
+			}
+			if isNoiseKeyword(word) {
+				break
+			}
+			pos = after
+		}
+	}
+	return start, end
+}
+
+// nextWord returns the word, or else the single rune, following the spaces at pos, and the position after it.
+func nextWord(input string, pos int) (string, int) {
+	pos += leftSpaceLength(input[pos:])
+	end := pos
+	for end < len(input) {
+		r, width := utf8.DecodeRuneInString(input[end:])
+		if !isWordRune(r) {
+			break
+		}
+		end += width
+	}
+	if end == pos && end < len(input) {
+		_, width := utf8.DecodeRuneInString(input[end:])
+		end += width
+	}
+	return input[pos:end], end
+}
+
+func leftSpaceLength(s string) int {
+	return len(s) - len(strings.TrimLeft(s, " \t"))
+}
+
+// emitWord emits the unquoted word scanned so far, as a number if it is one.
+func (l *lexer) emitWord() {
+	if isNumber(l.input[l.start:l.pos]) {
+		l.emit(itemNumber)
+		return
+	}
+	l.emit(itemInsideCheckProcess_ProgramMethodUnQuotedStringValue)
+}
+
 func (l *lexer) acceptNumbers() {
 	for unicode.IsNumber(l.next()) {
 	}
@@ -197,9 +500,27 @@ func (l *lexer) acceptUntilSpace() {
 	l.backup()
 }
 
+func (l *lexer) acceptUntilSpaceOrComma() {
+	for {
+		next := l.next()
+		if isSpace(next) || isEof(next) || isEndOfLine(next) || next == ',' {
+			break
+		}
+	}
+	l.backup()
+}
+
+// skipWhiteSpaces skips spaces and line ends, emitting any comment found among them.
 func (l *lexer) skipWhiteSpaces() {
-	l.pos += leftTrimLength(l.input[l.pos:])
-	l.ignore()
+	for {
+		l.pos += leftTrimLength(l.input[l.pos:])
+		l.ignore()
+		if !strings.HasPrefix(l.input[l.pos:], "#") {
+			return
+		}
+		l.acceptUntilEndOfLine()
+		l.emit(itemComment)
+	}
 }
 
 // backup steps back one rune.
@@ -228,6 +549,38 @@ func isEof(r rune) bool {
 	return r == eof
 }
 
+// noiseKeywords are the words monit ignores wherever they appear in a statement.
+var noiseKeywords = map[string]bool{
+	"if":     true,
+	"and":    true,
+	"with":   true,
+	"within": true,
+	"has":    true,
+	"using":  true,
+	"use":    true,
+	"on":     true,
+	"only":   true,
+	"then":   true,
+	"for":    true,
+	"of":     true,
+}
+
+// isNoiseKeyword reports whether word is a noise keyword, in any case.
+func isNoiseKeyword(word string) bool {
+	return noiseKeywords[strings.ToLower(word)]
+}
+
+// isWordRune reports whether r is part of a keyword or name, rather than ending it.
+func isWordRune(r rune) bool {
+	return r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isNumber reports whether word is an integer or decimal number, such as 30 or 2.5.
+func isNumber(word string) bool {
+	digits := strings.Replace(word, ".", "", 1)
+	return digits != "" && strings.Trim(digits, "0123456789") == ""
+}
+
 // isAlphaNumeric reports whether r is an alphabetic, digit, or underscore.
 func isAlphaNumeric(r rune) bool {
 	return r == '/' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
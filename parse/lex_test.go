@@ -1,6 +1,9 @@
 package lex
 
 import (
+	"context"
+	"strings"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -23,6 +26,150 @@ var _ = Describe("Lex", func() {
 
 			Eventually(items).Should(Receive())
 		})
+
+		It("Should track the line and column of every token", func() {
+			items := act(`check process abc
+  with pidfile /tmp/ü.pid
+
+check process def pidfile /tmp`)
+
+			Expect(<-items).To(EqualItemAt(Item{Type: itemCheckStart, Value: "check", Pos: 0, Line: 1, Column: 1}))
+			Expect(<-items).To(EqualItemAt(Item{Type: itemCheckProcess, Value: "process", Pos: 6, Line: 1, Column: 7}))
+			Expect(<-items).To(EqualItemAt(Item{Type: itemInsideCheckProcess_Name, Value: "abc", Pos: 14, Line: 1, Column: 15}))
+			Expect(<-items).To(EqualItemAt(Item{Type: itemInsideCheckProcess_Pid, Value: "pidfile", Pos: 25, Line: 2, Column: 8}))
+			Expect(<-items).To(EqualItemAt(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "/tmp/ü.pid", Pos: 33, Line: 2, Column: 16}))
+			Expect(<-items).To(EqualItemAt(Item{Type: itemCheckStart, Value: "check", Pos: 46, Line: 4, Column: 1}))
+			Expect(<-items).To(EqualItemAt(Item{Type: itemCheckProcess, Value: "process", Pos: 52, Line: 4, Column: 7}))
+			Expect(<-items).To(EqualItemAt(Item{Type: itemInsideCheckProcess_Name, Value: "def", Pos: 60, Line: 4, Column: 15}))
+			Expect(<-items).To(EqualItemAt(Item{Type: itemInsideCheckProcess_Pid, Value: "pidfile", Pos: 64, Line: 4, Column: 19}))
+			Expect(<-items).To(EqualItemAt(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "/tmp", Pos: 72, Line: 4, Column: 27}))
+			Expect(<-items).To(EqualItemAt(Item{Type: itemEOF, Value: "", Pos: 76, Line: 4, Column: 31}))
+		})
+	})
+
+	Context("Keywords", func() {
+		It("Should match keywords as whole words only", func() {
+			width := func(input string, keyword string) int {
+				_, end := scanKeyword(input, keyword)
+				return end
+			}
+			Expect(width("start program = \"/bin/start\"", "start")).To(Equal(5))
+			Expect(width("startup_delay 10", "start")).To(Equal(0))
+			Expect(width("permission 0644", "perm")).To(Equal(0))
+			Expect(width("read-only", "read")).To(Equal(0))
+			Expect(width("if   failed port 80", "if failed")).To(Equal(11))
+			Expect(width("if failedport 80", "if failed")).To(Equal(0))
+			Expect(width("if loadavg(1min)", "if loadavg")).To(Equal(10))
+		})
+
+		It("Should skip noise keywords in the input and treat those of the keyword as optional", func() {
+			start, end := scanKeyword("on port 80", "port")
+			Expect([]int{start, end}).To(Equal([]int{3, 7}))
+			start, end = scanKeyword("using pidfile /run/a.pid", "pidfile")
+			Expect([]int{start, end}).To(Equal([]int{6, 13}))
+			start, end = scanKeyword("timeout 5 seconds", "with timeout")
+			Expect([]int{start, end}).To(Equal([]int{0, 7}))
+			start, end = scanKeyword("depends on nginx", "depends on")
+			Expect([]int{start, end}).To(Equal([]int{0, 10}))
+			start, end = scanKeyword("depends nginx", "depends on")
+			Expect([]int{start, end}).To(Equal([]int{0, 7}))
+
+			_, end = scanKeyword("on then alert", "then")
+			Expect(end).To(Equal(0))
+			_, end = scanKeyword("with alert", "port")
+			Expect(end).To(Equal(0))
+		})
+
+		It("Should not read a keyword from within a name", func() {
+			items := act(`check file processlog path /var/log/process.log
+  if changed checksum then alert`)
+
+			Expect(<-items).To(EqualItem(Item{Type: itemCheckStart, Value: "check"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemCheckFile, Value: "file"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckFile_Name, Value: "processlog"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckFile_Path, Value: "path"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "/var/log/process.log"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckFile_IfChanged, Value: "if changed"}))
+		})
+
+		It("Should emit numbers, strings and operators as tokens of their own", func() {
+			items := act(`check system localhost
+  if loadavg (1min) > 4 then exec "/bin/page ops"`)
+
+			Expect(<-items).To(EqualItem(Item{Type: itemCheckStart, Value: "check"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemCheckSystem, Value: "system"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckSystem_Name, Value: "localhost"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckResourceTesting_LoadAvg, Value: "if loadavg"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckResourceTestingQualifier, Value: "1min"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckResourceTestingOperator, Value: ">"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemNumber, Value: "4"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Action, Value: "then"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "exec"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"/bin/page ops"`}))
+		})
+
+		It("Should emit the number and the unit of a resource limit as tokens of their own", func() {
+			items := act(`check system localhost
+  if memory > 80% for 3 cycles then alert
+  if swap > 1.5 GB then alert`)
+
+			Expect(<-items).To(EqualItem(Item{Type: itemCheckStart, Value: "check"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemCheckSystem, Value: "system"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckSystem_Name, Value: "localhost"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckResourceTesting_Memory, Value: "if memory"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckResourceTestingOperator, Value: ">"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemNumber, Value: "80"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckResourceTestingUnit, Value: "%"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Cycle, Value: "for"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemNumber, Value: "3"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "cycles"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Action, Value: "then"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "alert"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckResourceTesting_Swap, Value: "if swap"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckResourceTestingOperator, Value: ">"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemNumber, Value: "1.5"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckResourceTestingUnit, Value: "GB"}))
+			Expect(<-items).To(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Action, Value: "then"}))
+		})
+
+		It("Should report a statement that only starts with a keyword", func() {
+			items := act(`check process abc pidfile /tmp
+  startup_delay 10`)
+
+			var item Item
+			for item = range items {
+			}
+			Expect(item.Type).To(Equal(itemError))
+			Expect(item.Value).To(ContainSubstring("unexpected statement inside check"))
+		})
+	})
+
+	Context("Cancellation", func() {
+		It("Should stop scanning and close the channel once the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			_, items := LexContext(ctx, "test", strings.Repeat("check process abc pidfile /tmp\n", 1000))
+
+			Expect(<-items).To(EqualItem(Item{Type: itemCheckStart, Value: "check"}))
+			cancel()
+
+			received := 0
+			var item Item
+			for item = range items {
+				received++
+			}
+			Expect(received).To(BeNumerically("<", 10))
+			Expect(item.Type).To(Equal(itemError))
+			Expect(item.err).To(MatchError(context.Canceled))
+		})
+
+		It("Should close the channel of an input whose items are never read", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			l, items := LexContext(ctx, "test", "check process abc pidfile /tmp")
+			cancel()
+
+			Eventually(items).Should(BeClosed())
+			Expect(l.pos).To(BeNumerically("<", len(l.input)))
+		})
 	})
 
 })
@@ -1,9 +1,11 @@
 package lex
 
 import (
+	"fmt"
 	"github.com/DennisDenuto/golang-monit-parser/api"
-	"strings"
 	"strconv"
+	"strings"
+	"unicode"
 )
 
 type Parser struct{}
@@ -12,82 +14,309 @@ func NewMonitParser() Parser {
 	return Parser{}
 }
 
-func (Parser) Parse(items chan Item) MonitFileParsed {
+// Parse builds the monit tree from the items scanned by Lex.
+// Include statements are recorded in MonitFileParsed.Includes but not followed, see ParseFS.
+func (Parser) Parse(items chan Item) (MonitFileParsed, error) {
 	monitFileParsed := MonitFileParsed{}
+	p := &parseState{items: items}
+
+	if err := p.parse(&monitFileParsed); err != nil {
+		return MonitFileParsed{}, err
+	}
+	return monitFileParsed, nil
+}
+
+// parseState is a recursive descent parser over the item stream, with one item of lookahead.
+type parseState struct {
+	items     chan Item
+	token     Item // the item read ahead by peek or given back by backup.
+	peekCount int
+	comments  []string              // the comment lines just above token.
+	file      string                // the file being parsed, when known.
+	include   func(glob Item) error // follows an include statement, if set.
+}
+
+// parse adds the statements of the item stream to monitFileParsed.
+func (p *parseState) parse(monitFileParsed *MonitFileParsed) error {
+	if err := p.parseMonitFile(monitFileParsed); err != nil {
+		// let the lexer run to completion rather than block on an item nobody reads.
+		for range p.items {
+		}
+		return err
+	}
+	return nil
+}
 
-	for item := range items {
-		switch item.Type {
-		case itemCheckProcess:
-			monitFileParsed.CheckProcesses = append(monitFileParsed.CheckProcesses, api.ProcessCheck{})
-		case itemInsideCheckProcess_Name:
-			check := monitFileParsed.CheckProcesses.GetLast()
-			check.Name = item.Value
-		case itemInsideCheckProcess_Pid:
-			check := monitFileParsed.CheckProcesses.GetLast()
-			check.Pidfile = removeNoiseKeyword(item.Value)[len("pidfile "):]
-		case itemInsideCheckProcess_StartProgramMethod:
-			<-items
-			pathValue := <-items
-			<-items
-			uid := <-items
-			<-items
-			gid := <-items
-			check := monitFileParsed.CheckProcesses.GetLast()
-			check.StartProgram = api.CheckProgram{
-				Path: stripQuotes(pathValue.Value),
-				Uid:  stripQuotes(uid.Value),
-				Gid:  stripQuotes(gid.Value),
-			}
-		case itemInsideCheckProcess_StopProgramMethod:
-			<-items
-			pathValue := <-items
-			<-items
-			uid := <-items
-			<-items
-			gid := <-items
-			check := monitFileParsed.CheckProcesses.GetLast()
-			check.StopProgram = api.CheckProgram{
-				Path: stripQuotes(pathValue.Value),
-				Uid:  stripQuotes(uid.Value),
-				Gid:  stripQuotes(gid.Value),
-			}
-		case itemInsideCheckProcess_ConnectionTestingEnterIfConditions:
-			nextItem := <-items
-			switch nextItem.Type {
-			case itemInsideCheckProcess_ConnectionTesting_UnixSocket:
-				socketFilePath := <-items
-				<-items
-				timeout := <-items
-				<-items
-				timeoutValue, _ := strconv.Atoi(timeout.Value)
-
-				<-items
-				cycle := <-items
-				<-items
-				cycleValue, _ := strconv.Atoi(cycle.Value)
-
-				<-items
-				<-items
-				action := <-items
-
-				monitFileParsed.CheckProcesses.GetLast().FailedSocket.SocketFile = socketFilePath.Value
-				monitFileParsed.CheckProcesses.GetLast().FailedSocket.Timeout = timeoutValue
-				monitFileParsed.CheckProcesses.GetLast().FailedSocket.NumCycles = cycleValue
-				monitFileParsed.CheckProcesses.GetLast().FailedSocket.Action = action.Value
-			}
+// next returns the next item, or an itemEOF once the lexer is done.
+func (p *parseState) next() Item {
+	if p.peekCount > 0 {
+		p.peekCount--
+		return p.token
+	}
 
+	var comments []Item
+	for {
+		item, ok := <-p.items
+		if !ok {
+			item = Item{Type: itemEOF}
 		}
+		if item.Type != itemComment {
+			p.comments = leadingComments(comments, p.token, item)
+			p.token = item
+			return p.token
+		}
+		if len(comments) > 0 && comments[len(comments)-1].Line != item.Line-1 {
+			comments = nil
+		}
+		comments = append(comments, item)
+	}
+}
+
+// leadingComments returns the text of the comments on the lines just above item,
+// leaving out a comment trailing the previous item on its line.
+func leadingComments(comments []Item, previous Item, item Item) []string {
+	if len(comments) > 0 && comments[0].Line == previous.Line {
+		comments = comments[1:]
+	}
+	if len(comments) == 0 || comments[len(comments)-1].Line != item.Line-1 {
+		return nil
+	}
+
+	text := make([]string, len(comments))
+	for i, comment := range comments {
+		text[i] = strings.TrimSpace(strings.TrimPrefix(comment.Value, "#"))
+	}
+	return text
+}
+
+// backup gives back the item returned by next.
+// Can be called only once per call of next.
+func (p *parseState) backup() {
+	p.peekCount++
+}
+
+// peek returns but does not consume the next item.
+func (p *parseState) peek() Item {
+	item := p.next()
+	p.backup()
+	return item
+}
+
+// expect consumes the next item, which must be of type expected.
+func (p *parseState) expect(expected itemType, context string) (Item, error) {
+	item := p.next()
+	if item.Type != expected {
+		return item, p.unexpected(item, context)
+	}
+	return item, nil
+}
+
+// value consumes a quoted or unquoted string value.
+func (p *parseState) value(context string) (string, error) {
+	item := p.next()
+	if !isValue(item) {
+		return "", p.unexpected(item, context)
+	}
+	return stripQuotes(item.Value), nil
+}
+
+// keyword consumes a value naming one of monit's keywords, such as an action, in lower case.
+func (p *parseState) keyword(context string) (string, error) {
+	value, err := p.value(context)
+	return strings.ToLower(value), err
+}
+
+// action consumes the action following then, and the command of an exec action.
+func (p *parseState) action() (action string, exec string, err error) {
+	action, err = p.keyword("then")
+	if err == nil && action == "exec" && p.peek().Type == itemInsideCheckProcess_ProgramMethodQuotedStringValue {
+		exec, err = p.value("exec")
+	}
+	return action, exec, err
+}
+
+// number consumes a value holding an integer.
+func (p *parseState) number(context string) (int, error) {
+	item := p.next()
+	if !isValue(item) {
+		return 0, p.unexpected(item, context)
+	}
+	number, err := strconv.Atoi(stripQuotes(item.Value))
+	if err != nil {
+		return 0, p.errorf(item, "%s expects a number", context)
+	}
+	return number, nil
+}
+
+func isValue(item Item) bool {
+	return item.Type == itemInsideCheckProcess_ProgramMethodQuotedStringValue ||
+		item.Type == itemInsideCheckProcess_ProgramMethodUnQuotedStringValue ||
+		item.Type == itemNumber
+}
+
+// unexpected reports item as out of place, passing on the lexer's error if it could not scan any further.
+func (p *parseState) unexpected(item Item, context string) error {
+	switch item.Type {
+	case itemError:
+		return item.err
+	case itemEOF:
+		return p.errorf(item, "unexpected end of file in %s", context)
+	}
+	return p.errorf(item, "unexpected %s in %s", item, context)
+}
 
+func (p *parseState) errorf(item Item, format string, args ...interface{}) error {
+	return item.errorf(format, args...)
+}
+
+// ParseError reports a malformed monitrc file.
+type ParseError struct {
+	File    string // The name given to Lex.
+	Line    int    // 1-based line of the offending text.
+	Column  int    // 1-based column, in runes, of the offending text.
+	Snippet string // The offending text, up to the end of its line.
+	Msg     string
+}
+
+func (e *ParseError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("%d:%d: %s near %q", e.Line, e.Column, e.Msg, e.Snippet)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s near %q", e.File, e.Line, e.Column, e.Msg, e.Snippet)
+}
+
+var memUnits = map[string]int64{
+	"":          1,
+	"b":         1,
+	"byte":      1,
+	"bytes":     1,
+	"k":         1 << 10,
+	"kb":        1 << 10,
+	"kilobyte":  1 << 10,
+	"kilobytes": 1 << 10,
+	"m":         1 << 20,
+	"mb":        1 << 20,
+	"megabyte":  1 << 20,
+	"megabytes": 1 << 20,
+	"g":         1 << 30,
+	"gb":        1 << 30,
+	"gigabyte":  1 << 30,
+	"gigabytes": 1 << 30,
+	"t":         1 << 40,
+	"tb":        1 << 40,
+}
+
+/*
+parseMemLimit converts a resource limit such as 2048 "Mb" into bytes, or 80 "%" into a percentage.
+ */
+func parseMemLimit(number string, unit string) (bytes int64, percent float64, isPercent bool, err error) {
+	limit, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	if unit == "%" {
+		return 0, limit, true, nil
+	}
+
+	multiplier, ok := memUnits[strings.ToLower(unit)]
+	if !ok {
+		return 0, 0, false, fmt.Errorf("unknown memory unit '%s'", unit)
+	}
+	return int64(limit * float64(multiplier)), 0, false, nil
+}
+
+/*
+parseRate converts a rate such as 1 "MB/s" into bytes per second, or 500 "operations/s" into countUnit per second.
+ */
+func parseRate(number string, unit string, countUnit string) (limit int64, isCount bool, err error) {
+	if !strings.HasSuffix(strings.ToLower(unit), "/s") {
+		return 0, false, fmt.Errorf("expected a rate per second such as '1 MB/s'")
+	}
+	return parseAmount(number, unit[:len(unit)-len("/s")], countUnit)
+}
+
+/*
+parseAmount converts an amount such as 2 "GB" into bytes, or 500 "packets" into a count of countUnit.
+ */
+func parseAmount(number string, unit string, countUnit string) (limit int64, isCount bool, err error) {
+	amount, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, false, err
+	}
+
+	unit = strings.ToLower(unit)
+	if unit == countUnit {
+		return int64(amount), true, nil
+	}
+
+	multiplier, ok := memUnits[unit]
+	if !ok {
+		return 0, false, fmt.Errorf("unknown unit '%s'", unit)
 	}
+	return int64(amount * float64(multiplier)), false, nil
+}
 
-	return monitFileParsed
+var secondsUnits = map[string]int64{
+	"":        1,
+	"s":       1,
+	"second":  1,
+	"seconds": 1,
+	"m":       60,
+	"minute":  60,
+	"minutes": 60,
+	"h":       60 * 60,
+	"hour":    60 * 60,
+	"hours":   60 * 60,
+	"d":       24 * 60 * 60,
+	"day":     24 * 60 * 60,
+	"days":    24 * 60 * 60,
 }
 
 /*
-noise keywords like 'if', 'and', 'with(in)', 'has', 'us(ing|e)', 'on(ly)', 'then', 'for', 'of'
+parseSeconds converts a duration such as 3 "days" into seconds, where a number without a unit is in seconds.
  */
-func removeNoiseKeyword(val string) string {
-	return strings.TrimPrefix(val, "with ")
+func parseSeconds(number string, unit string) (int64, error) {
+	seconds, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	multiplier, ok := secondsUnits[strings.ToLower(unit)]
+	if !ok {
+		return 0, fmt.Errorf("unknown time unit '%s'", unit)
+	}
+	return seconds * multiplier, nil
+}
+
+/*
+parsePercent converts a limit such as 70 "%" into a percentage, where the "%" is optional.
+ */
+func parsePercent(number string, unit string) (float64, error) {
+	if unit != "" && unit != "%" {
+		return 0, fmt.Errorf("expected a percentage such as '70%%'")
+	}
+	return strconv.ParseFloat(number, 64)
+}
+
+/*
+splitQuantity splits a value such as "2048 Mb" or "80%" into its number and its unit.
+ */
+func splitQuantity(val string) (number string, unit string) {
+	val = strings.TrimSpace(val)
+	end := strings.IndexFunc(val, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+	if end < 0 {
+		return val, ""
+	}
+	return val[:end], strings.TrimSpace(val[end:])
+}
+
+func (p *parseState) position(item Item) api.Position {
+	return api.Position{File: p.file, Line: item.Line, Column: item.Column}
+}
+
+// hasKeywordPrefix reports whether val starts with the keyword, in any case.
+func hasKeywordPrefix(val string, keyword string) bool {
+	return len(val) >= len(keyword) && strings.EqualFold(val[:len(keyword)], keyword)
 }
 
 func stripQuotes(val string) string {
@@ -97,9 +326,19 @@ func stripQuotes(val string) string {
 type ProcessChecks []api.ProcessCheck
 
 func (pc ProcessChecks) GetLast() *api.ProcessCheck {
-	return &pc[len(pc) - 1]
+	return &pc[len(pc)-1]
 }
 
 type MonitFileParsed struct {
-	CheckProcesses ProcessChecks
+	Settings         api.Settings
+	Includes         []api.Include
+	CheckProcesses   ProcessChecks
+	CheckFiles       []api.FileCheck
+	CheckFilesystems []api.FilesystemCheck
+	CheckDirectories []api.DirectoryCheck
+	CheckFifos       []api.FifoCheck
+	CheckPrograms    []api.ProgramCheck
+	CheckNetworks    []api.NetworkCheck
+	CheckHosts       []api.HostCheck
+	CheckSystems     []api.SystemCheck
 }
@@ -0,0 +1,95 @@
+package lex
+
+import (
+	"github.com/DennisDenuto/golang-monit-parser/api"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ParseFS builds the monit tree from the monitrc file root in fsys, following its include statements.
+// The checks and settings of every included file are merged into one tree, and positions
+// name the file they were read from.
+// Absolute include globs are matched from the root of fsys, relative ones from the directory of the including file.
+func (Parser) ParseFS(fsys fs.FS, root string) (MonitFileParsed, error) {
+	monitFileParsed := MonitFileParsed{}
+	files := &includedFiles{fsys: fsys, reading: map[string]bool{}}
+
+	if err := files.parse(root, &monitFileParsed); err != nil {
+		return MonitFileParsed{}, err
+	}
+	return monitFileParsed, nil
+}
+
+// includedFiles parses a monitrc file and the files it includes.
+type includedFiles struct {
+	fsys    fs.FS
+	reading map[string]bool // the files on the current chain of includes.
+}
+
+func (files *includedFiles) parse(name string, monitFileParsed *MonitFileParsed) error {
+	contents, err := fs.ReadFile(files.fsys, name)
+	if err != nil {
+		return err
+	}
+
+	files.reading[name] = true
+	defer delete(files.reading, name)
+
+	_, items := Lex(name, string(contents))
+	p := &parseState{items: items, file: name}
+	p.include = func(glob Item) error {
+		return files.include(p, glob, monitFileParsed)
+	}
+	return p.parse(monitFileParsed)
+}
+
+// include parses the files matching glob, in lexical order, in place of the include statement.
+func (files *includedFiles) include(p *parseState, glob Item, monitFileParsed *MonitFileParsed) error {
+	pattern := stripQuotes(glob.Value)
+	if path.IsAbs(pattern) {
+		pattern = strings.TrimPrefix(pattern, "/")
+	} else {
+		pattern = path.Join(path.Dir(p.file), pattern)
+	}
+
+	matches, err := fs.Glob(files.fsys, pattern)
+	if err != nil {
+		return p.errorf(glob, "invalid include pattern: %s", err)
+	}
+	for _, match := range matches {
+		if files.reading[match] {
+			return p.errorf(glob, "include cycle through %s", match)
+		}
+		info, err := fs.Stat(files.fsys, match)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			continue
+		}
+		if err := files.parse(match, monitFileParsed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+INCLUDE <GLOB>
+ */
+func (p *parseState) parseInclude(monitFileParsed *MonitFileParsed, position api.Position) error {
+	glob := p.next()
+	if !isValue(glob) {
+		return p.unexpected(glob, "include")
+	}
+
+	monitFileParsed.Includes = append(monitFileParsed.Includes, api.Include{
+		Position: position,
+		Pattern:  stripQuotes(glob.Value),
+	})
+	if p.include == nil {
+		return nil
+	}
+	return p.include(glob)
+}
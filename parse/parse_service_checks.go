@@ -0,0 +1,1275 @@
+package lex
+
+import (
+	"github.com/DennisDenuto/golang-monit-parser/api"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// checkFields points at the fields of a check filled in by the statements of its block.
+// A nil field is a statement that check type does not support.
+type checkFields struct {
+	startProgram     *api.CheckProgram
+	stopProgram      *api.CheckProgram
+	failedSockets    *[]api.FailedSocket
+	failedHosts      *[]api.FailedHost
+	failedPings      *[]api.FailedPing
+	totalMemChecks   *[]api.MemUsage
+	ifChanged        *[]api.IfChanged
+	loadAvgChecks    *[]api.LoadAvg
+	cpuChecks        *[]api.CpuUsage
+	memoryChecks     *[]api.MemUsage
+	swapChecks       *[]api.MemUsage
+	uptimeChecks     *[]api.Uptime
+	spaceUsageChecks *[]api.DiskUsage
+	spaceFreeChecks  *[]api.DiskUsage
+	inodeUsageChecks *[]api.DiskUsage
+	inodeFreeChecks  *[]api.DiskUsage
+	readRateChecks   *[]api.IORate
+	writeRateChecks  *[]api.IORate
+	permission       *api.Permission
+	uid              *api.Owner
+	gid              *api.Owner
+	timestampChecks  *[]api.Timestamp
+	statusChecks     *[]api.ProgramStatus
+	failedLinks      *[]api.FailedLink
+	saturationChecks *[]api.Saturation
+	uploadChecks     *[]api.Bandwidth
+	downloadChecks   *[]api.Bandwidth
+	totalUploads     *[]api.Bandwidth
+	totalDownloads   *[]api.Bandwidth
+	alerts           *[]api.AlertRule
+	groups           *[]string
+	dependsOn        *[]string
+}
+
+func (p *parseState) parseMonitFile(monitFileParsed *MonitFileParsed) error {
+	for {
+		item := p.next()
+		switch item.Type {
+		case itemEOF:
+			return nil
+		case itemCheckStart:
+			if err := p.parseCheck(monitFileParsed, p.position(item), p.comments); err != nil {
+				return err
+			}
+		case itemSetStart:
+			if err := p.parseSet(&monitFileParsed.Settings, p.position(item)); err != nil {
+				return err
+			}
+		case itemInclude:
+			if err := p.parseInclude(monitFileParsed, p.position(item)); err != nil {
+				return err
+			}
+		default:
+			return p.unexpected(item, "monit file")
+		}
+	}
+}
+
+func (p *parseState) parseCheck(monitFileParsed *MonitFileParsed, position api.Position, comments []string) error {
+	item := p.next()
+	switch item.Type {
+	case itemCheckProcess:
+		check, err := p.parseProcessCheck(position)
+		if err != nil {
+			return err
+		}
+		check.Comments = comments
+		monitFileParsed.CheckProcesses = append(monitFileParsed.CheckProcesses, check)
+	case itemCheckFile:
+		check, err := p.parseFileCheck(position)
+		if err != nil {
+			return err
+		}
+		check.Comments = comments
+		monitFileParsed.CheckFiles = append(monitFileParsed.CheckFiles, check)
+	case itemCheckFilesystem:
+		check, err := p.parseFilesystemCheck(position)
+		if err != nil {
+			return err
+		}
+		check.Comments = comments
+		monitFileParsed.CheckFilesystems = append(monitFileParsed.CheckFilesystems, check)
+	case itemCheckDirectory:
+		check, err := p.parseDirectoryCheck(position)
+		if err != nil {
+			return err
+		}
+		check.Comments = comments
+		monitFileParsed.CheckDirectories = append(monitFileParsed.CheckDirectories, check)
+	case itemCheckFifo:
+		check, err := p.parseFifoCheck(position)
+		if err != nil {
+			return err
+		}
+		check.Comments = comments
+		monitFileParsed.CheckFifos = append(monitFileParsed.CheckFifos, check)
+	case itemCheckProgram:
+		check, err := p.parseProgramCheck(position)
+		if err != nil {
+			return err
+		}
+		check.Comments = comments
+		monitFileParsed.CheckPrograms = append(monitFileParsed.CheckPrograms, check)
+	case itemCheckNetwork:
+		check, err := p.parseNetworkCheck(position)
+		if err != nil {
+			return err
+		}
+		check.Comments = comments
+		monitFileParsed.CheckNetworks = append(monitFileParsed.CheckNetworks, check)
+	case itemCheckHost:
+		check, err := p.parseHostCheck(position)
+		if err != nil {
+			return err
+		}
+		check.Comments = comments
+		monitFileParsed.CheckHosts = append(monitFileParsed.CheckHosts, check)
+	case itemCheckSystem:
+		check, err := p.parseSystemCheck(position)
+		if err != nil {
+			return err
+		}
+		check.Comments = comments
+		monitFileParsed.CheckSystems = append(monitFileParsed.CheckSystems, check)
+	default:
+		return p.unexpected(item, "check")
+	}
+	return nil
+}
+
+/*
+CHECK PROCESS <unique name> <PIDFILE <path> | MATCHING <regex>>
+ */
+func (p *parseState) parseProcessCheck(position api.Position) (api.ProcessCheck, error) {
+	check := api.ProcessCheck{Position: position}
+
+	name, err := p.expect(itemInsideCheckProcess_Name, "check process")
+	if err != nil {
+		return check, err
+	}
+	check.Name = name.Value
+
+	err = p.parseProcessIdentity(&check)
+	if err != nil {
+		return check, err
+	}
+
+	err = p.parseStatements(checkFields{
+		startProgram:   &check.StartProgram,
+		stopProgram:    &check.StopProgram,
+		failedSockets:  &check.FailedSockets,
+		failedHosts:    &check.FailedHosts,
+		totalMemChecks: &check.TotalMemChecks,
+		cpuChecks:      &check.CpuChecks,
+		memoryChecks:   &check.MemoryChecks,
+		alerts:         &check.Alerts,
+		groups:         &check.Groups,
+		dependsOn:      &check.DependsOn,
+	})
+	return check, err
+}
+
+/*
+[WITH] <PIDFILE <path> | MATCHING <regex>>, which is optional
+ */
+func (p *parseState) parseProcessIdentity(check *api.ProcessCheck) error {
+	var err error
+	switch item := p.next(); item.Type {
+	case itemInsideCheckProcess_Pid:
+		check.Pidfile, err = p.value("pidfile")
+	case itemInsideCheckProcess_Matching:
+		pattern := p.peek()
+		check.Matching, err = p.value("matching")
+		if err != nil {
+			return err
+		}
+		if _, err := regexp.Compile(check.Matching); err != nil {
+			return p.errorf(pattern, "invalid matching pattern: %s", err)
+		}
+	default:
+		p.backup()
+	}
+	return err
+}
+
+/*
+CHECK FILE <unique name> PATH <path>
+ */
+func (p *parseState) parseFileCheck(position api.Position) (api.FileCheck, error) {
+	check := api.FileCheck{Position: position}
+
+	name, err := p.expect(itemInsideCheckFile_Name, "check file")
+	if err != nil {
+		return check, err
+	}
+	check.Name = name.Value
+
+	_, err = p.expect(itemInsideCheckFile_Path, "check file")
+	if err != nil {
+		return check, err
+	}
+	check.Path, err = p.value("path")
+	if err != nil {
+		return check, err
+	}
+
+	err = p.parseStatements(checkFields{
+		startProgram:   &check.StartProgram,
+		stopProgram:    &check.StopProgram,
+		failedSockets:  &check.FailedSockets,
+		failedHosts:    &check.FailedHosts,
+		totalMemChecks: &check.TotalMemChecks,
+		ifChanged:      &check.IfChangedChecks,
+		alerts:         &check.Alerts,
+		groups:         &check.Groups,
+		dependsOn:      &check.DependsOn,
+	})
+	return check, err
+}
+
+/*
+CHECK FILESYSTEM <unique name> [WITH] PATH <mountpoint | device>
+ */
+func (p *parseState) parseFilesystemCheck(position api.Position) (api.FilesystemCheck, error) {
+	check := api.FilesystemCheck{Position: position}
+
+	name, err := p.expect(itemInsideCheckFilesystem_Name, "check filesystem")
+	if err != nil {
+		return check, err
+	}
+	check.Name = name.Value
+
+	_, err = p.expect(itemInsideCheckFile_Path, "check filesystem")
+	if err != nil {
+		return check, err
+	}
+	check.Path, err = p.value("path")
+	if err != nil {
+		return check, err
+	}
+
+	err = p.parseStatements(checkFields{
+		startProgram:     &check.StartProgram,
+		stopProgram:      &check.StopProgram,
+		spaceUsageChecks: &check.SpaceUsageChecks,
+		spaceFreeChecks:  &check.SpaceFreeChecks,
+		inodeUsageChecks: &check.InodeUsageChecks,
+		inodeFreeChecks:  &check.InodeFreeChecks,
+		readRateChecks:   &check.ReadRateChecks,
+		writeRateChecks:  &check.WriteRateChecks,
+		permission:       &check.Permission,
+		ifChanged:        &check.IfChangedChecks,
+		alerts:           &check.Alerts,
+		groups:           &check.Groups,
+		dependsOn:        &check.DependsOn,
+	})
+	return check, err
+}
+
+/*
+CHECK DIRECTORY <unique name> [WITH] PATH <path>
+ */
+func (p *parseState) parseDirectoryCheck(position api.Position) (api.DirectoryCheck, error) {
+	check := api.DirectoryCheck{Position: position}
+
+	name, err := p.expect(itemInsideCheckDirectory_Name, "check directory")
+	if err != nil {
+		return check, err
+	}
+	check.Name = name.Value
+
+	_, err = p.expect(itemInsideCheckFile_Path, "check directory")
+	if err != nil {
+		return check, err
+	}
+	check.Path, err = p.value("path")
+	if err != nil {
+		return check, err
+	}
+
+	err = p.parseStatements(checkFields{
+		startProgram:    &check.StartProgram,
+		stopProgram:     &check.StopProgram,
+		permission:      &check.Permission,
+		uid:             &check.Uid,
+		gid:             &check.Gid,
+		timestampChecks: &check.TimestampChecks,
+		ifChanged:       &check.IfChangedChecks,
+		alerts:          &check.Alerts,
+		groups:          &check.Groups,
+		dependsOn:       &check.DependsOn,
+	})
+	return check, err
+}
+
+/*
+CHECK FIFO <unique name> [WITH] PATH <path>
+ */
+func (p *parseState) parseFifoCheck(position api.Position) (api.FifoCheck, error) {
+	check := api.FifoCheck{Position: position}
+
+	name, err := p.expect(itemInsideCheckFifo_Name, "check fifo")
+	if err != nil {
+		return check, err
+	}
+	check.Name = name.Value
+
+	_, err = p.expect(itemInsideCheckFile_Path, "check fifo")
+	if err != nil {
+		return check, err
+	}
+	check.Path, err = p.value("path")
+	if err != nil {
+		return check, err
+	}
+
+	err = p.parseStatements(checkFields{
+		startProgram:    &check.StartProgram,
+		stopProgram:     &check.StopProgram,
+		permission:      &check.Permission,
+		uid:             &check.Uid,
+		gid:             &check.Gid,
+		timestampChecks: &check.TimestampChecks,
+		ifChanged:       &check.IfChangedChecks,
+		alerts:          &check.Alerts,
+		groups:          &check.Groups,
+		dependsOn:       &check.DependsOn,
+	})
+	return check, err
+}
+
+/*
+CHECK PROGRAM <unique name> [WITH] PATH <executable file> [[WITH] TIMEOUT <number> SECONDS]
+ */
+func (p *parseState) parseProgramCheck(position api.Position) (api.ProgramCheck, error) {
+	check := api.ProgramCheck{Position: position}
+
+	name, err := p.expect(itemInsideCheckProgram_Name, "check program")
+	if err != nil {
+		return check, err
+	}
+	check.Name = name.Value
+
+	_, err = p.expect(itemInsideCheckProgram_Path, "check program")
+	if err != nil {
+		return check, err
+	}
+	check.Path, err = p.value("path")
+	if err != nil {
+		return check, err
+	}
+
+	if p.peek().Type == itemInsideCheckProcess_ConnectionTesting_Timeout {
+		p.next()
+		check.Timeout, err = p.parseQuantity("timeout")
+		if err != nil {
+			return check, err
+		}
+	}
+
+	err = p.parseStatements(checkFields{
+		startProgram: &check.StartProgram,
+		stopProgram:  &check.StopProgram,
+		statusChecks: &check.StatusChecks,
+		ifChanged:    &check.IfChangedChecks,
+		alerts:       &check.Alerts,
+		groups:       &check.Groups,
+		dependsOn:    &check.DependsOn,
+	})
+	return check, err
+}
+
+/*
+CHECK NETWORK <unique name> [WITH] <ADDRESS <ip address> | INTERFACE <name>>
+ */
+func (p *parseState) parseNetworkCheck(position api.Position) (api.NetworkCheck, error) {
+	check := api.NetworkCheck{Position: position}
+
+	name, err := p.expect(itemInsideCheckNetwork_Name, "check network")
+	if err != nil {
+		return check, err
+	}
+	check.Name = name.Value
+
+	switch item := p.next(); item.Type {
+	case itemInsideCheckNetwork_Interface:
+		check.Interface, err = p.value("interface")
+	case itemInsideCheckHost_Address:
+		check.Address, err = p.value("address")
+	default:
+		err = p.unexpected(item, "check network")
+	}
+	if err != nil {
+		return check, err
+	}
+
+	err = p.parseStatements(checkFields{
+		startProgram:     &check.StartProgram,
+		stopProgram:      &check.StopProgram,
+		failedLinks:      &check.FailedLinks,
+		saturationChecks: &check.SaturationChecks,
+		uploadChecks:     &check.UploadChecks,
+		downloadChecks:   &check.DownloadChecks,
+		totalUploads:     &check.TotalUploadChecks,
+		totalDownloads:   &check.TotalDownloadChecks,
+		ifChanged:        &check.IfChangedChecks,
+		alerts:           &check.Alerts,
+		groups:           &check.Groups,
+		dependsOn:        &check.DependsOn,
+	})
+	return check, err
+}
+
+/*
+CHECK HOST <unique name> [WITH] ADDRESS <host name | IP address>
+ */
+func (p *parseState) parseHostCheck(position api.Position) (api.HostCheck, error) {
+	check := api.HostCheck{Position: position}
+
+	name, err := p.expect(itemInsideCheckHost_Name, "check host")
+	if err != nil {
+		return check, err
+	}
+	check.Name = name.Value
+
+	_, err = p.expect(itemInsideCheckHost_Address, "check host")
+	if err != nil {
+		return check, err
+	}
+	check.Address, err = p.value("address")
+	if err != nil {
+		return check, err
+	}
+
+	err = p.parseStatements(checkFields{
+		startProgram: &check.StartProgram,
+		stopProgram:  &check.StopProgram,
+		failedHosts:  &check.FailedHosts,
+		failedPings:  &check.FailedPings,
+		alerts:       &check.Alerts,
+		groups:       &check.Groups,
+		dependsOn:    &check.DependsOn,
+	})
+	return check, err
+}
+
+/*
+CHECK SYSTEM <unique name>
+ */
+func (p *parseState) parseSystemCheck(position api.Position) (api.SystemCheck, error) {
+	check := api.SystemCheck{Position: position}
+
+	name, err := p.expect(itemInsideCheckSystem_Name, "check system")
+	if err != nil {
+		return check, err
+	}
+	check.Name = name.Value
+
+	err = p.parseStatements(checkFields{
+		loadAvgChecks: &check.LoadAvgChecks,
+		cpuChecks:     &check.CpuChecks,
+		memoryChecks:  &check.MemoryChecks,
+		swapChecks:    &check.SwapChecks,
+		uptimeChecks:  &check.UptimeChecks,
+		alerts:        &check.Alerts,
+		groups:        &check.Groups,
+		dependsOn:     &check.DependsOn,
+	})
+	return check, err
+}
+
+// parseStatements parses the statements of a check block, up to the next check or the end of the file.
+func (p *parseState) parseStatements(fields checkFields) error {
+	for {
+		item := p.next()
+
+		var err error
+		switch item.Type {
+		case itemCheckStart, itemSetStart, itemInclude, itemEOF:
+			p.backup()
+			return nil
+		case itemInsideCheckProcess_StartProgramMethod:
+			if fields.startProgram == nil {
+				return p.unsupported(item)
+			}
+			*fields.startProgram, err = p.parseProgramMethod(item)
+		case itemInsideCheckProcess_StopProgramMethod:
+			if fields.stopProgram == nil {
+				return p.unsupported(item)
+			}
+			*fields.stopProgram, err = p.parseProgramMethod(item)
+		case itemInsideCheckProcess_ProgramMethodGroupName:
+			if fields.groups == nil {
+				return p.unsupported(item)
+			}
+			var group string
+			group, err = p.value("group")
+			*fields.groups = append(*fields.groups, group)
+		case itemServiceDependencies:
+			if fields.dependsOn == nil {
+				return p.unsupported(item)
+			}
+			var dependsOn []string
+			dependsOn, err = p.parseDependencies()
+			*fields.dependsOn = append(*fields.dependsOn, dependsOn...)
+		case itemInsideCheckProcess_ConnectionTestingEnterIfConditions:
+			err = p.parseConnectionTest(item, fields)
+		case itemInsideCheckResourceTesting:
+			if fields.totalMemChecks == nil {
+				return p.unsupported(item)
+			}
+			var memUsage api.MemUsage
+			memUsage, err = p.parseMemTest(item)
+			*fields.totalMemChecks = append(*fields.totalMemChecks, memUsage)
+		case itemInsideCheckProcess_Alert, itemInsideCheckProcess_NoAlert:
+			if fields.alerts == nil {
+				return p.unsupported(item)
+			}
+			var alert api.AlertRule
+			alert, err = p.parseAlert()
+			alert.Position = p.position(item)
+			alert.NoAlert = item.Type == itemInsideCheckProcess_NoAlert
+			*fields.alerts = append(*fields.alerts, alert)
+		case itemInsideCheckFile_IfChanged:
+			if fields.ifChanged == nil {
+				return p.unsupported(item)
+			}
+			var ifChanged api.IfChanged
+			ifChanged, err = p.parseChangedTest(item)
+			*fields.ifChanged = append(*fields.ifChanged, ifChanged)
+		case itemInsideCheckResourceTesting_LoadAvg:
+			if fields.loadAvgChecks == nil {
+				return p.unsupported(item)
+			}
+			var loadAvg api.LoadAvg
+			loadAvg, err = p.parseLoadAvgTest(item)
+			*fields.loadAvgChecks = append(*fields.loadAvgChecks, loadAvg)
+		case itemInsideCheckResourceTesting_Cpu:
+			if fields.cpuChecks == nil {
+				return p.unsupported(item)
+			}
+			var cpuUsage api.CpuUsage
+			cpuUsage, err = p.parseCpuTest(item)
+			*fields.cpuChecks = append(*fields.cpuChecks, cpuUsage)
+		case itemInsideCheckResourceTesting_Memory:
+			if fields.memoryChecks == nil {
+				return p.unsupported(item)
+			}
+			var memUsage api.MemUsage
+			memUsage, err = p.parseMemTest(item)
+			*fields.memoryChecks = append(*fields.memoryChecks, memUsage)
+		case itemInsideCheckResourceTesting_Swap:
+			if fields.swapChecks == nil {
+				return p.unsupported(item)
+			}
+			var swapUsage api.MemUsage
+			swapUsage, err = p.parseMemTest(item)
+			*fields.swapChecks = append(*fields.swapChecks, swapUsage)
+		case itemInsideCheckResourceTesting_SpaceUsage:
+			err = p.appendDiskTest(item, fields.spaceUsageChecks)
+		case itemInsideCheckResourceTesting_SpaceFree:
+			err = p.appendDiskTest(item, fields.spaceFreeChecks)
+		case itemInsideCheckResourceTesting_InodeUsage:
+			err = p.appendDiskTest(item, fields.inodeUsageChecks)
+		case itemInsideCheckResourceTesting_InodeFree:
+			err = p.appendDiskTest(item, fields.inodeFreeChecks)
+		case itemInsideCheckResourceTesting_ReadRate:
+			err = p.appendRateTest(item, fields.readRateChecks)
+		case itemInsideCheckResourceTesting_WriteRate:
+			err = p.appendRateTest(item, fields.writeRateChecks)
+		case itemInsideCheckResourceTesting_Timestamp:
+			if fields.timestampChecks == nil {
+				return p.unsupported(item)
+			}
+			var timestamp api.Timestamp
+			timestamp, err = p.parseTimestampTest(item)
+			*fields.timestampChecks = append(*fields.timestampChecks, timestamp)
+		case itemInsideCheckResourceTesting_Status:
+			if fields.statusChecks == nil {
+				return p.unsupported(item)
+			}
+			var status api.ProgramStatus
+			status, err = p.parseStatusTest(item)
+			*fields.statusChecks = append(*fields.statusChecks, status)
+		case itemInsideCheckResourceTesting_Saturation:
+			if fields.saturationChecks == nil {
+				return p.unsupported(item)
+			}
+			var saturation api.Saturation
+			saturation, err = p.parseSaturationTest(item)
+			*fields.saturationChecks = append(*fields.saturationChecks, saturation)
+		case itemInsideCheckResourceTesting_Upload:
+			err = p.appendBandwidthTest(item, fields.uploadChecks, false)
+		case itemInsideCheckResourceTesting_Download:
+			err = p.appendBandwidthTest(item, fields.downloadChecks, false)
+		case itemInsideCheckResourceTesting_TotalUpload:
+			err = p.appendBandwidthTest(item, fields.totalUploads, true)
+		case itemInsideCheckResourceTesting_TotalDownload:
+			err = p.appendBandwidthTest(item, fields.totalDownloads, true)
+		case itemInsideCheckResourceTesting_Uptime:
+			if fields.uptimeChecks == nil {
+				return p.unsupported(item)
+			}
+			var uptime api.Uptime
+			uptime, err = p.parseUptimeTest(item)
+			*fields.uptimeChecks = append(*fields.uptimeChecks, uptime)
+		default:
+			return p.unexpected(item, "check")
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *parseState) unsupported(item Item) error {
+	return p.errorf(item, "statement not supported by this check type")
+}
+
+/*
+<START | STOP> PROGRAM = "program" [[AS] UID user] [[AND] GID group] [[WITH] TIMEOUT number SECONDS]
+ */
+func (p *parseState) parseProgramMethod(method Item) (api.CheckProgram, error) {
+	program := api.CheckProgram{Position: p.position(method)}
+
+	var err error
+	program.Path, err = p.value(method.Value)
+	if err != nil {
+		return program, err
+	}
+
+	for {
+		switch p.next().Type {
+		case itemInsideCheckProcess_ProgramMethodUid:
+			program.Uid, err = p.value("uid")
+		case itemInsideCheckProcess_ProgramMethodGid:
+			program.Gid, err = p.value("gid")
+		case itemInsideCheckProcess_ProgramMethodTimeout:
+			program.Timeout, err = p.parseQuantity("timeout")
+		default:
+			p.backup()
+			return program, nil
+		}
+
+		if err != nil {
+			return program, err
+		}
+	}
+}
+
+/*
+DEPENDS on service[, service [,...]]
+ */
+func (p *parseState) parseDependencies() ([]string, error) {
+	dependency, err := p.value("depends on")
+	if err != nil {
+		return nil, err
+	}
+	dependsOn := []string{dependency}
+
+	for isValue(p.peek()) {
+		dependency, _ = p.value("depends on")
+		dependsOn = append(dependsOn, dependency)
+	}
+	return dependsOn, nil
+}
+
+/*
+IF FAILED <UNIXSOCKET path | [HOST host] PORT port [TYPE {TCP|UDP|TCPSSL}] [PROTOCOL protocol] | PING [COUNT number]> [TIMEOUT number SECONDS] [FOR number CYCLES] THEN action
+ */
+func (p *parseState) parseConnectionTest(ifFailed Item, fields checkFields) error {
+	switch item := p.peek(); item.Type {
+	case itemInsideCheckProcess_ConnectionTesting_UnixSocket:
+		if fields.failedSockets == nil {
+			return p.unsupported(item)
+		}
+		failedSocket, err := p.parseFailedSocket(ifFailed)
+		*fields.failedSockets = append(*fields.failedSockets, failedSocket)
+		return err
+	case itemInsideCheckProcess_ConnectionTesting_TcpUdpHost,
+		itemInsideCheckProcess_ConnectionTesting_TcpUdpPort,
+		itemInsideCheckProcess_ConnectionTesting_TcpUdpProtocol,
+		itemInsideCheckProcess_ConnectionTesting_TcpUdpType:
+		if fields.failedHosts == nil {
+			return p.unsupported(item)
+		}
+		failedHost, err := p.parseFailedHost(ifFailed)
+		*fields.failedHosts = append(*fields.failedHosts, failedHost)
+		return err
+	case itemInsideCheckFile_Permission:
+		if fields.permission == nil {
+			return p.unsupported(item)
+		}
+		permission, err := p.parseFailedPermission(ifFailed)
+		*fields.permission = permission
+		return err
+	case itemInsideCheckFile_Uid:
+		if fields.uid == nil {
+			return p.unsupported(item)
+		}
+		uid, err := p.parseFailedOwner(ifFailed, "uid")
+		*fields.uid = uid
+		return err
+	case itemInsideCheckFile_Gid:
+		if fields.gid == nil {
+			return p.unsupported(item)
+		}
+		gid, err := p.parseFailedOwner(ifFailed, "gid")
+		*fields.gid = gid
+		return err
+	case itemInsideCheckNetwork_Link:
+		if fields.failedLinks == nil {
+			return p.unsupported(item)
+		}
+		failedLink, err := p.parseFailedLink(ifFailed)
+		*fields.failedLinks = append(*fields.failedLinks, failedLink)
+		return err
+	case itemInsideCheckProcess_ConnectionTesting_Ping:
+		if fields.failedPings == nil {
+			return p.unsupported(item)
+		}
+		failedPing, err := p.parseFailedPing(ifFailed)
+		*fields.failedPings = append(*fields.failedPings, failedPing)
+		return err
+	default:
+		p.next()
+		return p.unexpected(item, "if failed")
+	}
+}
+
+func (p *parseState) parseFailedSocket(ifFailed Item) (api.FailedSocket, error) {
+	failedSocket := api.FailedSocket{Position: p.position(ifFailed)}
+
+	_, err := p.expect(itemInsideCheckProcess_ConnectionTesting_UnixSocket, "if failed")
+	if err != nil {
+		return failedSocket, err
+	}
+	failedSocket.SocketFile, err = p.value("unixsocket")
+	if err != nil {
+		return failedSocket, err
+	}
+
+	for {
+		item := p.next()
+		switch item.Type {
+		case itemInsideCheckProcess_ConnectionTesting_Timeout:
+			failedSocket.Timeout, err = p.parseQuantity("timeout")
+		case itemInsideCheckProcess_ConnectionTesting_Cycle:
+			failedSocket.NumCycles, err = p.parseQuantity("for")
+		case itemInsideCheckProcess_ConnectionTesting_ExitIfConditions:
+		case itemInsideCheckProcess_ConnectionTesting_Action:
+			failedSocket.Action, failedSocket.Exec, err = p.action()
+			return failedSocket, err
+		default:
+			return failedSocket, p.unexpected(item, "if failed unixsocket")
+		}
+
+		if err != nil {
+			return failedSocket, err
+		}
+	}
+}
+
+func (p *parseState) parseFailedHost(ifFailed Item) (api.FailedHost, error) {
+	failedHost := api.FailedHost{Position: p.position(ifFailed)}
+
+	for {
+		var err error
+		item := p.next()
+		switch item.Type {
+		case itemInsideCheckProcess_ConnectionTesting_TcpUdpHost:
+			failedHost.Host, err = p.value("host")
+		case itemInsideCheckProcess_ConnectionTesting_TcpUdpPort:
+			failedHost.Port, err = p.number("port")
+		case itemInsideCheckProcess_ConnectionTesting_TcpUdpProtocol:
+			failedHost.Protocol, err = p.keyword("protocol")
+		case itemInsideCheckProcess_ConnectionTesting_TcpUdpType:
+			failedHost.Type, err = p.keyword("type")
+			if err == nil && failedHost.Type != "tcp" && failedHost.Type != "udp" && failedHost.Type != "tcpssl" {
+				err = p.errorf(item, "unknown connection type '%s'", failedHost.Type)
+			}
+		case itemInsideCheckProcess_ConnectionTesting_Timeout:
+			failedHost.Timeout, err = p.parseQuantity("timeout")
+		case itemInsideCheckProcess_ConnectionTesting_Cycle:
+			failedHost.NumCycles, err = p.parseQuantity("for")
+		case itemInsideCheckProcess_ConnectionTesting_ExitIfConditions:
+		case itemInsideCheckProcess_ConnectionTesting_Action:
+			failedHost.Action, failedHost.Exec, err = p.action()
+			return failedHost, err
+		default:
+			return failedHost, p.unexpected(item, "if failed host")
+		}
+
+		if err != nil {
+			return failedHost, err
+		}
+	}
+}
+
+func (p *parseState) parseFailedPing(ifFailed Item) (api.FailedPing, error) {
+	failedPing := api.FailedPing{Position: p.position(ifFailed)}
+
+	_, err := p.expect(itemInsideCheckProcess_ConnectionTesting_Ping, "if failed")
+	if err != nil {
+		return failedPing, err
+	}
+
+	for {
+		item := p.next()
+		switch item.Type {
+		case itemInsideCheckProcess_ConnectionTesting_Count:
+			failedPing.Count, err = p.number("count")
+		case itemInsideCheckProcess_ConnectionTesting_Timeout:
+			failedPing.Timeout, err = p.parseQuantity("timeout")
+		case itemInsideCheckProcess_ConnectionTesting_Cycle:
+			failedPing.NumCycles, err = p.parseQuantity("for")
+		case itemInsideCheckProcess_ConnectionTesting_ExitIfConditions:
+		case itemInsideCheckProcess_ConnectionTesting_Action:
+			failedPing.Action, failedPing.Exec, err = p.action()
+			return failedPing, err
+		default:
+			return failedPing, p.unexpected(item, "if failed ping")
+		}
+
+		if err != nil {
+			return failedPing, err
+		}
+	}
+}
+
+/*
+IF FAILED LINK [FOR number CYCLES] THEN action
+ */
+func (p *parseState) parseFailedLink(ifFailed Item) (api.FailedLink, error) {
+	failedLink := api.FailedLink{Position: p.position(ifFailed)}
+
+	_, err := p.expect(itemInsideCheckNetwork_Link, "if failed")
+	if err != nil {
+		return failedLink, err
+	}
+
+	for {
+		item := p.next()
+		switch item.Type {
+		case itemInsideCheckProcess_ConnectionTesting_Cycle:
+			failedLink.NumCycles, err = p.parseQuantity("for")
+		case itemInsideCheckProcess_ConnectionTesting_ExitIfConditions:
+		case itemInsideCheckProcess_ConnectionTesting_Action:
+			failedLink.Action, failedLink.Exec, err = p.action()
+			return failedLink, err
+		default:
+			return failedLink, p.unexpected(item, "if failed link")
+		}
+
+		if err != nil {
+			return failedLink, err
+		}
+	}
+}
+
+// resourceTest is a resource test as written, before its limit is interpreted.
+type resourceTest struct {
+	position   api.Position
+	qualifier  string
+	operator   string
+	limit      Item
+	unit       string
+	period     Item
+	periodUnit string
+	numCycles  int
+	action     string
+	exec       string
+}
+
+/*
+IF resource [(qualifier)] operator value [unit] [FOR number CYCLES] THEN action
+ */
+func (p *parseState) parseResourceTest(ifResource Item) (resourceTest, error) {
+	test := resourceTest{position: p.position(ifResource)}
+
+	for {
+		var err error
+		item := p.next()
+		switch item.Type {
+		case itemInsideCheckResourceTestingQualifier:
+			test.qualifier = strings.ToLower(item.Value)
+		case itemInsideCheckResourceTestingOperator:
+			test.operator = item.Value
+
+			test.limit = p.next()
+			if !isValue(test.limit) {
+				return test, p.unexpected(test.limit, ifResource.Value)
+			}
+			if p.peek().Type == itemInsideCheckResourceTestingUnit {
+				test.unit = p.next().Value
+			}
+		case itemInsideCheckResourceTestingPeriod:
+			test.period = p.next()
+			if !isValue(test.period) {
+				return test, p.unexpected(test.period, "in last")
+			}
+			test.periodUnit, err = p.value("in last")
+		case itemInsideCheckProcess_ConnectionTesting_Cycle:
+			test.numCycles, err = p.parseQuantity("for")
+		case itemInsideCheckProcess_ConnectionTesting_Action:
+			if test.operator == "" {
+				return test, p.errorf(ifResource, "%s missing a limit", ifResource.Value)
+			}
+			test.action, test.exec, err = p.action()
+			return test, err
+		default:
+			return test, p.unexpected(item, ifResource.Value)
+		}
+
+		if err != nil {
+			return test, err
+		}
+	}
+}
+
+/*
+IF <TOTAL MEMORY | MEMORY | SWAP> operator value [unit] [FOR number CYCLES] THEN action
+ */
+func (p *parseState) parseMemTest(ifMemory Item) (api.MemUsage, error) {
+	test, err := p.parseResourceTest(ifMemory)
+	if err != nil {
+		return api.MemUsage{}, err
+	}
+
+	memUsage := api.MemUsage{
+		Position:  test.position,
+		Operator:  test.operator,
+		NumCycles: test.numCycles,
+		Action:    test.action,
+		Exec:      test.exec,
+	}
+	memUsage.MemLimit, memUsage.PercentLimit, memUsage.IsPercent, err = parseMemLimit(test.limit.Value, test.unit)
+	if err != nil {
+		return memUsage, p.errorf(test.limit, "%s", err)
+	}
+	return memUsage, nil
+}
+
+/*
+IF <SPACE | INODE> <USAGE | FREE> operator value [unit] [FOR number CYCLES] THEN action
+ */
+func (p *parseState) appendDiskTest(ifDisk Item, checks *[]api.DiskUsage) error {
+	if checks == nil {
+		return p.unsupported(ifDisk)
+	}
+
+	test, err := p.parseResourceTest(ifDisk)
+	if err != nil {
+		return err
+	}
+
+	diskUsage := api.DiskUsage{
+		Position:  test.position,
+		Operator:  test.operator,
+		NumCycles: test.numCycles,
+		Action:    test.action,
+		Exec:      test.exec,
+	}
+	diskUsage.Limit, diskUsage.PercentLimit, diskUsage.IsPercent, err = parseMemLimit(test.limit.Value, test.unit)
+	if err != nil {
+		return p.errorf(test.limit, "%s", err)
+	}
+	*checks = append(*checks, diskUsage)
+	return nil
+}
+
+/*
+IF <READ | WRITE> RATE operator value <B | KB | MB | GB | OPERATIONS>/S [FOR number CYCLES] THEN action
+ */
+func (p *parseState) appendRateTest(ifRate Item, checks *[]api.IORate) error {
+	if checks == nil {
+		return p.unsupported(ifRate)
+	}
+
+	test, err := p.parseResourceTest(ifRate)
+	if err != nil {
+		return err
+	}
+
+	rate := api.IORate{
+		Position:  test.position,
+		Operator:  test.operator,
+		NumCycles: test.numCycles,
+		Action:    test.action,
+		Exec:      test.exec,
+	}
+	rate.Limit, rate.IsOperations, err = parseRate(test.limit.Value, test.unit, "operations")
+	if err != nil {
+		return p.errorf(test.limit, "%s", err)
+	}
+	*checks = append(*checks, rate)
+	return nil
+}
+
+/*
+IF LOADAVG (<1MIN | 5MIN | 15MIN>) operator value [FOR number CYCLES] THEN action
+ */
+func (p *parseState) parseLoadAvgTest(ifLoadAvg Item) (api.LoadAvg, error) {
+	test, err := p.parseResourceTest(ifLoadAvg)
+	if err != nil {
+		return api.LoadAvg{}, err
+	}
+
+	loadAvg := api.LoadAvg{
+		Position:  test.position,
+		Period:    test.qualifier,
+		Operator:  test.operator,
+		NumCycles: test.numCycles,
+		Action:    test.action,
+		Exec:      test.exec,
+	}
+	loadAvg.Limit, err = strconv.ParseFloat(test.limit.Value, 64)
+	if err != nil || test.unit != "" {
+		return loadAvg, p.errorf(test.limit, "loadavg expects a number")
+	}
+	return loadAvg, nil
+}
+
+/*
+IF CPU [USAGE] [(<USER | SYSTEM | WAIT>)] operator value% [FOR number CYCLES] THEN action
+ */
+func (p *parseState) parseCpuTest(ifCpu Item) (api.CpuUsage, error) {
+	test, err := p.parseResourceTest(ifCpu)
+	if err != nil {
+		return api.CpuUsage{}, err
+	}
+
+	cpuUsage := api.CpuUsage{
+		Position:  test.position,
+		Kind:      test.qualifier,
+		Operator:  test.operator,
+		NumCycles: test.numCycles,
+		Action:    test.action,
+		Exec:      test.exec,
+	}
+	cpuUsage.PercentLimit, err = parsePercent(test.limit.Value, test.unit)
+	if err != nil {
+		return cpuUsage, p.errorf(test.limit, "cpu usage expects a percentage")
+	}
+	return cpuUsage, nil
+}
+
+/*
+IF TIMESTAMP operator value <SECONDS | MINUTES | HOURS | DAYS> [FOR number CYCLES] THEN action
+ */
+func (p *parseState) parseTimestampTest(ifTimestamp Item) (api.Timestamp, error) {
+	test, err := p.parseResourceTest(ifTimestamp)
+	if err != nil {
+		return api.Timestamp{}, err
+	}
+
+	timestamp := api.Timestamp{
+		Position:  test.position,
+		Operator:  test.operator,
+		NumCycles: test.numCycles,
+		Action:    test.action,
+		Exec:      test.exec,
+	}
+	timestamp.Seconds, err = parseSeconds(test.limit.Value, test.unit)
+	if err != nil {
+		return timestamp, p.errorf(test.limit, "%s", err)
+	}
+	return timestamp, nil
+}
+
+/*
+IF STATUS operator value [FOR number CYCLES] THEN action
+ */
+func (p *parseState) parseStatusTest(ifStatus Item) (api.ProgramStatus, error) {
+	test, err := p.parseResourceTest(ifStatus)
+	if err != nil {
+		return api.ProgramStatus{}, err
+	}
+
+	status := api.ProgramStatus{
+		Position:  test.position,
+		Operator:  test.operator,
+		NumCycles: test.numCycles,
+		Action:    test.action,
+		Exec:      test.exec,
+	}
+	status.Status, err = strconv.Atoi(test.limit.Value)
+	if err != nil || test.unit != "" {
+		return status, p.errorf(test.limit, "status expects an exit status")
+	}
+	return status, nil
+}
+
+/*
+IF SATURATION operator value% [FOR number CYCLES] THEN action
+ */
+func (p *parseState) parseSaturationTest(ifSaturation Item) (api.Saturation, error) {
+	test, err := p.parseResourceTest(ifSaturation)
+	if err != nil {
+		return api.Saturation{}, err
+	}
+
+	saturation := api.Saturation{
+		Position:  test.position,
+		Operator:  test.operator,
+		NumCycles: test.numCycles,
+		Action:    test.action,
+		Exec:      test.exec,
+	}
+	saturation.PercentLimit, err = parsePercent(test.limit.Value, test.unit)
+	if err != nil {
+		return saturation, p.errorf(test.limit, "saturation expects a percentage")
+	}
+	return saturation, nil
+}
+
+/*
+IF <UPLOAD | DOWNLOAD> operator value <B | KB | MB | GB | PACKETS>/S [FOR number CYCLES] THEN action
+IF TOTAL <UPLOAD | DOWNLOAD> operator value <B | KB | MB | GB | PACKETS> IN LAST number <SECONDS | MINUTES | HOURS | DAYS> [FOR number CYCLES] THEN action
+ */
+func (p *parseState) appendBandwidthTest(ifBandwidth Item, checks *[]api.Bandwidth, total bool) error {
+	if checks == nil {
+		return p.unsupported(ifBandwidth)
+	}
+
+	test, err := p.parseResourceTest(ifBandwidth)
+	if err != nil {
+		return err
+	}
+
+	bandwidth := api.Bandwidth{
+		Position:  test.position,
+		Operator:  test.operator,
+		Period:    1,
+		NumCycles: test.numCycles,
+		Action:    test.action,
+		Exec:      test.exec,
+	}
+	if !total {
+		bandwidth.Limit, bandwidth.IsPackets, err = parseRate(test.limit.Value, test.unit, "packets")
+		if err != nil {
+			return p.errorf(test.limit, "%s", err)
+		}
+		*checks = append(*checks, bandwidth)
+		return nil
+	}
+
+	if test.period.Value == "" {
+		return p.errorf(ifBandwidth, "%s missing 'in last' period", ifBandwidth.Value)
+	}
+	bandwidth.Limit, bandwidth.IsPackets, err = parseAmount(test.limit.Value, test.unit, "packets")
+	if err != nil {
+		return p.errorf(test.limit, "%s", err)
+	}
+	bandwidth.Period, err = parseSeconds(test.period.Value, test.periodUnit)
+	if err != nil {
+		return p.errorf(test.period, "%s", err)
+	}
+	*checks = append(*checks, bandwidth)
+	return nil
+}
+
+/*
+IF UPTIME operator value <SECONDS | MINUTES | HOURS | DAYS> [FOR number CYCLES] THEN action
+ */
+func (p *parseState) parseUptimeTest(ifUptime Item) (api.Uptime, error) {
+	test, err := p.parseResourceTest(ifUptime)
+	if err != nil {
+		return api.Uptime{}, err
+	}
+
+	uptime := api.Uptime{
+		Position:  test.position,
+		Operator:  test.operator,
+		NumCycles: test.numCycles,
+		Action:    test.action,
+		Exec:      test.exec,
+	}
+	uptime.Seconds, err = parseSeconds(test.limit.Value, test.unit)
+	if err != nil {
+		return uptime, p.errorf(test.limit, "%s", err)
+	}
+	return uptime, nil
+}
+
+/*
+IF FAILED PERM[ISSION] octalnumber THEN action
+ */
+func (p *parseState) parseFailedPermission(ifFailed Item) (api.Permission, error) {
+	permission := api.Permission{Position: p.position(ifFailed)}
+
+	perm, err := p.expect(itemInsideCheckFile_Permission, "if failed")
+	if err != nil {
+		return permission, err
+	}
+	permission.Mode, err = p.value("permission")
+	if err != nil {
+		return permission, err
+	}
+	if _, err := strconv.ParseUint(permission.Mode, 8, 32); err != nil {
+		return permission, p.errorf(perm, "permission expects an octal mode")
+	}
+
+	_, err = p.expect(itemInsideCheckProcess_ConnectionTesting_Action, "if failed permission")
+	if err != nil {
+		return permission, err
+	}
+	permission.Action, permission.Exec, err = p.action()
+	return permission, err
+}
+
+/*
+IF FAILED <UID | GID> <name | id> THEN action
+ */
+func (p *parseState) parseFailedOwner(ifFailed Item, context string) (api.Owner, error) {
+	owner := api.Owner{Position: p.position(ifFailed)}
+
+	p.next()
+	var err error
+	owner.Name, err = p.value(context)
+	if err != nil {
+		return owner, err
+	}
+
+	_, err = p.expect(itemInsideCheckProcess_ConnectionTesting_Action, "if failed "+context)
+	if err != nil {
+		return owner, err
+	}
+	owner.Action, owner.Exec, err = p.action()
+	return owner, err
+}
+
+/*
+IF CHANGED <attribute> THEN action
+ */
+func (p *parseState) parseChangedTest(ifChanged Item) (api.IfChanged, error) {
+	changed := api.IfChanged{Position: p.position(ifChanged)}
+
+	var attributes []string
+	for isValue(p.peek()) {
+		attribute, _ := p.keyword("if changed")
+		attributes = append(attributes, attribute)
+	}
+	changed.Attribute = strings.Join(attributes, " ")
+
+	_, err := p.expect(itemInsideCheckProcess_ConnectionTesting_Action, "if changed")
+	if err != nil {
+		return changed, err
+	}
+	changed.Action, changed.Exec, err = p.action()
+	return changed, err
+}
+
+// parseQuantity parses the "number unit" following a keyword such as timeout or for, e.g. "5 cycles".
+func (p *parseState) parseQuantity(context string) (int, error) {
+	quantity, err := p.number(context)
+	if err != nil {
+		return 0, err
+	}
+	_, err = p.value(context)
+	return quantity, err
+}
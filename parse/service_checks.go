@@ -4,44 +4,120 @@ import (
 	"strings"
 	"fmt"
 	"errors"
+	"unicode"
 )
 
 func ServiceCheckStart(l *lexer) stateFn {
 	l.skipWhiteSpaces()
-	l.pos += len("check")
-	l.emit(itemCheckStart)
+	if isEof(l.peek()) {
+		return l.emitEOF()
+	}
+	if l.hasKeyword("set") {
+		l.inCheck = false
+		return SetStart
+	}
+	if l.hasKeyword("include") {
+		l.inCheck = false
+		return IncludeStart
+	}
+	if !l.emitKeyword("check", itemCheckStart) {
+		return l.errorf("expected 'check', 'set' or 'include' statement")
+	}
+	l.inCheck = true
 
-	if x := strings.Index(l.input[l.pos:], "process"); x >= 0 {
-		l.skipWhiteSpaces()
+	if l.hasKeyword("process") {
 		return ServiceCheckProcessStart
 	}
 
-	if x := strings.Index(l.input[l.pos:], "file"); x >= 0 {
-		l.skipWhiteSpaces()
+	if l.hasKeyword("filesystem") {
+		return ServiceCheckFilesystemStart
+	}
+
+	if l.hasKeyword("file") {
 		return ServiceCheckFileStart
 	}
 
-	return nil
+	if l.hasKeyword("directory") {
+		return ServiceCheckDirectoryStart
+	}
+
+	if l.hasKeyword("fifo") {
+		return ServiceCheckFifoStart
+	}
+
+	if l.hasKeyword("program") {
+		return ServiceCheckProgramStart
+	}
+
+	if l.hasKeyword("network") {
+		return ServiceCheckNetworkStart
+	}
+
+	if l.hasKeyword("host") {
+		return ServiceCheckHostStart
+	}
+
+	if l.hasKeyword("system") {
+		return ServiceCheckSystemStart
+	}
+
+	return l.errorf("unsupported check service type")
 }
 
 func ServiceCheckProcessStart(l *lexer) stateFn {
-	l.pos += len("process")
-	l.emit(itemCheckProcess)
-
-	l.skipWhiteSpaces()
+	l.emitKeyword("process", itemCheckProcess)
 
 	return ServiceInsideCheckProcess
 }
 
 func ServiceCheckFileStart(l *lexer) stateFn {
-	l.pos += len("file")
-	l.emit(itemCheckFile)
-
-	l.skipWhiteSpaces()
+	l.emitKeyword("file", itemCheckFile)
 
 	return ServiceInsideCheckFile
 }
 
+func ServiceCheckFilesystemStart(l *lexer) stateFn {
+	l.emitKeyword("filesystem", itemCheckFilesystem)
+
+	return ServiceInsideCheckFilesystem
+}
+
+func ServiceCheckDirectoryStart(l *lexer) stateFn {
+	l.emitKeyword("directory", itemCheckDirectory)
+
+	return ServiceInsideCheckDirectory
+}
+
+func ServiceCheckFifoStart(l *lexer) stateFn {
+	l.emitKeyword("fifo", itemCheckFifo)
+
+	return ServiceInsideCheckFifo
+}
+
+func ServiceCheckProgramStart(l *lexer) stateFn {
+	l.emitKeyword("program", itemCheckProgram)
+
+	return ServiceInsideCheckProgram
+}
+
+func ServiceCheckNetworkStart(l *lexer) stateFn {
+	l.emitKeyword("network", itemCheckNetwork)
+
+	return ServiceInsideCheckNetwork
+}
+
+func ServiceCheckHostStart(l *lexer) stateFn {
+	l.emitKeyword("host", itemCheckHost)
+
+	return ServiceInsideCheckHost
+}
+
+func ServiceCheckSystemStart(l *lexer) stateFn {
+	l.emitKeyword("system", itemCheckSystem)
+
+	return ServiceInsideCheckSystem
+}
+
 func ServiceInsideCheckProcess(l *lexer) stateFn {
 	for {
 		switch nextRune := l.next(); {
@@ -58,224 +134,397 @@ func ServiceInsideCheckProcess(l *lexer) stateFn {
 			return ServiceInsideCheckProcessPid
 		case isEof(nextRune):
 			l.emit(itemInsideCheckProcess_Name)
-			return nil
+			return l.emitEOF()
 		}
 	}
-
-	return nil
 }
+
 func ServiceInsideCheckFile(l *lexer) stateFn {
 	for {
 		switch nextRune := l.next(); {
 		case isAlphaNumeric(nextRune):
-		case isSpace(nextRune):
+		case isSpace(nextRune) || isEndOfLine(nextRune):
 			l.backup()
 			l.emit(itemInsideCheckFile_Name)
 			l.skipWhiteSpaces()
 
-			if strings.HasPrefix(l.input[l.pos:], "path") {
+			if l.hasKeyword("path") {
 				return ServiceInsideCheckPath
 			}
 			return l.errorf("check file <path> missing")
-		case isEndOfLine(nextRune):
-			l.backup()
-			l.emit(itemInsideCheckFile_Name)
-			l.skipWhiteSpaces()
-			return nil
 		case isEof(nextRune):
 			l.emit(itemInsideCheckFile_Name)
-			return nil
+			return l.emitEOF()
 		}
 	}
+}
 
-	return nil
+/*
+CHECK FILESYSTEM <unique name> [WITH] PATH <mountpoint | device>
+ */
+func ServiceInsideCheckFilesystem(l *lexer) stateFn {
+	return insideCheckWithPath(l, itemInsideCheckFilesystem_Name, "filesystem")
 }
 
-func ServiceInsideCheckPath(l *lexer) stateFn {
-	l.pos += len("path")
+/*
+CHECK DIRECTORY <unique name> [WITH] PATH <path>
+ */
+func ServiceInsideCheckDirectory(l *lexer) stateFn {
+	return insideCheckWithPath(l, itemInsideCheckDirectory_Name, "directory")
+}
+
+/*
+CHECK FIFO <unique name> [WITH] PATH <path>
+ */
+func ServiceInsideCheckFifo(l *lexer) stateFn {
+	return insideCheckWithPath(l, itemInsideCheckFifo_Name, "fifo")
+}
+
+/*
+CHECK PROGRAM <unique name> [WITH] PATH <executable file> [[WITH] TIMEOUT <number> SECONDS]
+ */
+func ServiceInsideCheckProgram(l *lexer) stateFn {
+	l.acceptUntilSpace()
+	l.emit(itemInsideCheckProgram_Name)
 	l.skipWhiteSpaces()
 
-	for {
-		switch nextRune := l.next(); {
-		case isAlphaNumeric(nextRune):
-		case isSpace(nextRune):
-			l.backup()
-			l.emit(itemInsideCheckFile_Path)
-			l.skipWhiteSpaces()
-			return ServiceInsideCheckProcessMethods
-		case isEndOfLine(nextRune):
-			l.backup()
-			l.emit(itemInsideCheckFile_Path)
-			l.skipWhiteSpaces()
-			return ServiceInsideCheckProcessMethods
-		case isEof(nextRune):
-			l.emit(itemInsideCheckFile_Path)
-			return nil
+	if !l.emitKeyword("path", itemInsideCheckProgram_Path) {
+		return l.errorf("check program <path> missing")
+	}
+	err := emitStringValue(l)
+	if err != nil {
+		return l.errorf("%s", err)
+	}
+	l.skipWhiteSpaces()
+
+	if l.emitKeyword("with timeout", itemInsideCheckProcess_ConnectionTesting_Timeout) {
+		err := emitStringValue(l)
+		if err != nil {
+			return l.errorf("%s", err)
 		}
+		err = emitStringValue(l)
+		if err != nil {
+			return l.errorf("%s", err)
+		}
+		l.skipWhiteSpaces()
 	}
 
-	return nil
+	return ServiceInsideCheckProcessMethods
+}
+
+// insideCheckWithPath scans the unique name and path of a check monitoring a path.
+func insideCheckWithPath(l *lexer, name itemType, service string) stateFn {
+	l.acceptUntilSpace()
+	l.emit(name)
+	l.skipWhiteSpaces()
+
+	if !l.hasKeyword("path") {
+		return l.errorf("check %s <path> missing", service)
+	}
+	return ServiceInsideCheckPath
+}
+
+/*
+CHECK HOST <unique name> [WITH] ADDRESS <host name | IP address>
+ */
+func ServiceInsideCheckHost(l *lexer) stateFn {
+	l.acceptUntilSpace()
+	l.emit(itemInsideCheckHost_Name)
+	l.skipWhiteSpaces()
+
+	if !l.emitKeyword("address", itemInsideCheckHost_Address) {
+		return l.errorf("check host <address> missing")
+	}
+
+	err := emitStringValue(l)
+	if err != nil {
+		return l.errorf("%s", err)
+	}
+	return ServiceInsideCheckProcessMethods
 }
 
+/*
+CHECK NETWORK <unique name> [WITH] <ADDRESS <ip address> | INTERFACE <name>>
+ */
+func ServiceInsideCheckNetwork(l *lexer) stateFn {
+	l.acceptUntilSpace()
+	l.emit(itemInsideCheckNetwork_Name)
+	l.skipWhiteSpaces()
+
+	if !l.emitKeyword("interface", itemInsideCheckNetwork_Interface) && !l.emitKeyword("address", itemInsideCheckHost_Address) {
+		return l.errorf("check network <interface | address> missing")
+	}
+
+	err := emitStringValue(l)
+	if err != nil {
+		return l.errorf("%s", err)
+	}
+	return ServiceInsideCheckProcessMethods
+}
+
+/*
+CHECK SYSTEM <unique name>
+ */
+func ServiceInsideCheckSystem(l *lexer) stateFn {
+	l.acceptUntilSpace()
+	l.emit(itemInsideCheckSystem_Name)
+	l.skipWhiteSpaces()
+
+	return ServiceInsideCheckProcessMethods
+}
+
+/*
+[WITH] PATH <path>, where the path may be quoted
+ */
+func ServiceInsideCheckPath(l *lexer) stateFn {
+	l.emitKeyword("path", itemInsideCheckFile_Path)
+	err := emitStringValue(l)
+	if err != nil {
+		return l.errorf("%s", err)
+	}
+	return ServiceInsideCheckProcessMethods
+}
+
+/*
+[WITH] <PIDFILE <path> | MATCHING <regex>>, which is optional
+ */
 func ServiceInsideCheckProcessPid(l *lexer) stateFn {
-	for {
-		switch nextRune := l.next(); {
-		case isEndOfLine(nextRune):
-			l.backup()
-			l.emit(itemInsideCheckProcess_Pid)
-			l.next()
-			l.ignore()
+	if !l.emitKeyword("pidfile", itemInsideCheckProcess_Pid) && !l.emitKeyword("matching", itemInsideCheckProcess_Matching) {
+		return ServiceInsideCheckProcessMethods
+	}
 
-			if isSpace(l.next()) {
-				l.skipWhiteSpaces()
-				return ServiceInsideCheckProcessMethods
-			}
-			l.backup()
-			return ServiceCheckStart
-		case isEof(nextRune):
-			l.emit(itemInsideCheckProcess_Pid)
-			return nil
-		}
+	err := emitStringValue(l)
+	if err != nil {
+		return l.errorf("%s", err)
 	}
-	return nil
+	return ServiceInsideCheckProcessMethods
 }
 
 func ServiceInsideCheckProcessMethods(l *lexer) stateFn {
-	if strings.HasPrefix(l.input[l.pos:], "start") || strings.HasPrefix(l.input[l.pos:], "stop") {
-		localItemInsideCheckProcessProgramMethod := itemInsideCheckProcess_StartProgramMethod
-
-		if strings.HasPrefix(l.input[l.pos:], "stop") {
-			localItemInsideCheckProcessProgramMethod = itemInsideCheckProcess_StopProgramMethod
+	// START [PROGRAM] [=] <command>
+	if l.acceptKeyword("start program") || l.acceptKeyword("start") {
+		return insideProgramMethod(l, itemInsideCheckProcess_StartProgramMethod)
+	}
+	if l.acceptKeyword("stop program") || l.acceptKeyword("stop") {
+		return insideProgramMethod(l, itemInsideCheckProcess_StopProgramMethod)
+	}
+	if l.emitKeyword("group", itemInsideCheckProcess_ProgramMethodGroupName) {
+		err := emitStringValue(l)
+		if err != nil {
+			return l.errorf("%s", err)
 		}
 
+		return ServiceInsideCheckProcessMethods
+	}
+	if l.emitKeyword("depends on", itemServiceDependencies) {
+
+		// DEPENDS on service[, service [,...]]
 		for {
-			switch nextRune := l.next(); {
-			case isAlphaNumeric(nextRune):
-			case nextRune == '=':
-				l.backup()
-				for {
-					if isSpace(l.current()) {
-						l.backup()
-					} else {
-						break
-					}
-				}
-
-				l.emit(localItemInsideCheckProcessProgramMethod)
-				l.emit(itemInsideCheckProcess_ProgramMethodPath)
-				l.acceptRun(" =")
-				l.ignore()
-
-				err := emitStringValue(l)
-				if err != nil {
-					return l.errorf(err.Error())
-				}
-				return ServiceInsideCheckProcessMethods
-			case isEndOfLine(nextRune) || isEof(nextRune):
-				return l.errorf("check process start missing '='", l.input[l.start:l.pos])
+			l.acceptUntilSpaceOrComma()
+			if l.pos == l.start {
+				return l.errorf("depends on missing service name")
+			}
+			l.emit(itemInsideCheckProcess_ProgramMethodUnQuotedStringValue)
+			l.acceptRun(" \t")
+			if !l.accept(",") {
+				break
 			}
+			l.skipWhiteSpaces()
 		}
+		l.skipWhiteSpaces()
 
 		return ServiceInsideCheckProcessMethods
 	}
-	if strings.HasPrefix(l.input[l.pos:], "as") {
-		l.pos += len("as")
-		l.skipWhiteSpaces()
-		l.ignore()
-		switch {
-		case strings.HasPrefix(l.input[l.pos:], "uid"):
-			l.pos += len("uid")
-			l.emit(itemInsideCheckProcess_ProgramMethodUid)
-			l.skipWhiteSpaces()
-
-			err := emitStringValue(l)
-			if err != nil {
-				return l.errorf(err.Error())
-			}
-			return ServiceInsideCheckProcessMethods
+	if l.emitKeyword("if failed", itemInsideCheckProcess_ConnectionTestingEnterIfConditions) {
+		return ServiceInsideCheckProcessConnectionTesting
+	}
+	if l.emitKeyword("if total memory", itemInsideCheckResourceTesting) {
+		return InsideCheckResourceTesting
+	}
+	for _, resource := range resourceTests {
+		if l.emitKeyword(resource.keyword, resource.item) {
+			return InsideCheckResourceTesting
 		}
 	}
-	if strings.HasPrefix(l.input[l.pos:], "and") {
-		l.pos += len("and")
-		l.skipWhiteSpaces()
-		l.ignore()
-		switch {
-		case strings.HasPrefix(l.input[l.pos:], "gid"):
-			l.pos += len("gid")
-			l.emit(itemInsideCheckProcess_ProgramMethodGid)
-			l.skipWhiteSpaces()
-			err := emitStringValue(l)
-			if err != nil {
-				return l.errorf(err.Error())
-			}
-
-			return ServiceInsideCheckProcessMethods
+	if l.emitKeyword("alert", itemInsideCheckProcess_Alert) || l.emitKeyword("noalert", itemInsideCheckProcess_NoAlert) {
+		err := emitSetValue(l)
+		if err != nil {
+			return l.errorf("%s", err)
 		}
+		return InsideSetStatement
 	}
-	if strings.HasPrefix(l.input[l.pos:], "group") {
-		l.pos += len("group")
-		l.emit(itemInsideCheckProcess_ProgramMethodGroupName)
-		l.skipWhiteSpaces()
+	if l.emitKeyword("if changed", itemInsideCheckFile_IfChanged) {
+		return InsideCheckChangedTesting
+	}
+	if l.hasKeyword("check", "set", "include") {
+		return ServiceCheckStart
+	}
+	if isEof(l.peek()) {
+		return l.emitEOF()
+	}
+	return l.errorf("unexpected statement inside check")
+}
+
+// insideProgramMethod scans the command of the start or stop method accepted so far, where the '=' is optional.
+func insideProgramMethod(l *lexer, method itemType) stateFn {
+	keyword := l.input[l.start:l.pos]
+	l.emit(method)
+	l.acceptRun(" \t=")
+	l.ignore()
+
+	if next := l.peek(); isEndOfLine(next) || isEof(next) {
+		return l.errorf("%s missing command", keyword)
+	}
+	err := emitStringValue(l)
+	if err != nil {
+		return l.errorf("%s", err)
+	}
+	return InsideProgramMethodOptions
+}
+
+/*
+[[AS] UID <user>] [[AND] GID <group>] [[WITH] TIMEOUT <number> SECONDS], in any order after the command of a program method
+ */
+func InsideProgramMethodOptions(l *lexer) stateFn {
+	if l.acceptKeyword("as") && !l.hasKeyword("uid", "gid") {
+		return l.errorf("program method expects 'uid' or 'gid'")
+	}
+	if l.emitKeyword("uid", itemInsideCheckProcess_ProgramMethodUid) || l.emitKeyword("gid", itemInsideCheckProcess_ProgramMethodGid) {
 		err := emitStringValue(l)
 		if err != nil {
-			return l.errorf(err.Error())
+			return l.errorf("%s", err)
 		}
-
-		return ServiceInsideCheckProcessMethods
+		return InsideProgramMethodOptions
 	}
-	if strings.HasPrefix(l.input[l.pos:], "depends on") {
-		l.pos += len("depends on")
-		l.emit(itemServiceDependencies)
-		l.skipWhiteSpaces()
+	if l.emitKeyword("with timeout", itemInsideCheckProcess_ProgramMethodTimeout) {
 		err := emitStringValue(l)
 		if err != nil {
-			return l.errorf(err.Error())
+			return l.errorf("%s", err)
 		}
-
-		return ServiceInsideCheckProcessMethods
+		err = emitStringValue(l)
+		if err != nil {
+			return l.errorf("%s", err)
+		}
+		return InsideProgramMethodOptions
 	}
-	if strings.HasPrefix(l.input[l.pos:], "if failed") {
-		l.pos += len("if failed")
-		l.emit(itemInsideCheckProcess_ConnectionTestingEnterIfConditions)
-		l.skipWhiteSpaces()
+	return ServiceInsideCheckProcessMethods(l)
+}
+
+// resourceTests are the keywords starting a resource test, longest keyword first.
+var resourceTests = []struct {
+	keyword string
+	item    itemType
+}{
+	{"if loadavg", itemInsideCheckResourceTesting_LoadAvg},
+	{"if cpu usage", itemInsideCheckResourceTesting_Cpu},
+	{"if cpu", itemInsideCheckResourceTesting_Cpu},
+	{"if memory usage", itemInsideCheckResourceTesting_Memory},
+	{"if memory", itemInsideCheckResourceTesting_Memory},
+	{"if swap usage", itemInsideCheckResourceTesting_Swap},
+	{"if swap", itemInsideCheckResourceTesting_Swap},
+	{"if uptime", itemInsideCheckResourceTesting_Uptime},
+	{"if space usage", itemInsideCheckResourceTesting_SpaceUsage},
+	{"if space free", itemInsideCheckResourceTesting_SpaceFree},
+	{"if space", itemInsideCheckResourceTesting_SpaceUsage},
+	{"if inode usage", itemInsideCheckResourceTesting_InodeUsage},
+	{"if inode free", itemInsideCheckResourceTesting_InodeFree},
+	{"if inode", itemInsideCheckResourceTesting_InodeUsage},
+	{"if read rate", itemInsideCheckResourceTesting_ReadRate},
+	{"if write rate", itemInsideCheckResourceTesting_WriteRate},
+	{"if timestamp", itemInsideCheckResourceTesting_Timestamp},
+	{"if status", itemInsideCheckResourceTesting_Status},
+	{"if saturation", itemInsideCheckResourceTesting_Saturation},
+	{"if total upload", itemInsideCheckResourceTesting_TotalUpload},
+	{"if total download", itemInsideCheckResourceTesting_TotalDownload},
+	{"if upload", itemInsideCheckResourceTesting_Upload},
+	{"if download", itemInsideCheckResourceTesting_Download},
+}
+
+/*
+IF CHANGED {CHECKSUM|TIMESTAMP|...} THEN action
+ */
+func InsideCheckChangedTesting(l *lexer) stateFn {
+	if l.hasKeyword("then") {
 		return ServiceInsideCheckProcessConnectionTesting
 	}
-	if strings.HasPrefix(l.input[l.pos:], "if total memory") {
-		l.pos += len("if total memory")
-		l.emit(itemInsideCheckResourceTesting)
-		l.skipWhiteSpaces()
-		return InsideCheckResourceTesting
+
+	if !isAlphaNumeric(l.peek()) {
+		return l.errorf("if changed missing 'then': %s", l.input[l.start:l.pos])
 	}
-	return nil
+	err := emitStringValue(l)
+	if err != nil {
+		return l.errorf("%s", err)
+	}
+	return InsideCheckChangedTesting
 }
 
+/*
+IF resource [(qualifier)] operator value [unit] [FOR number CYCLES] THEN action
+ */
 func InsideCheckResourceTesting(l *lexer) stateFn {
+	if l.accept("(") {
+		l.ignore()
+		for next := l.next(); next != ')'; next = l.next() {
+			if isEndOfLine(next) || isEof(next) {
+				return l.errorf("resource test missing ')'")
+			}
+		}
+		l.backup()
+		l.emit(itemInsideCheckResourceTestingQualifier)
+		l.next()
+		l.ignore()
+		l.skipWhiteSpaces()
 
-	if l.accept("><=") {
+		return InsideCheckResourceTesting
+	}
+
+	if l.accept("><=!") {
 		l.accept("><=")
 		l.emit(itemInsideCheckResourceTestingOperator)
 
 		l.skipWhiteSpaces()
 		l.acceptNumbers()
+		if l.accept(".") {
+			l.acceptNumbers()
+		}
+		l.emitWord()
+
 		l.acceptRun(" ")
-		l.acceptRun("%kmgbKMGB")
-		l.emit(itemInsideCheckProcess_ProgramMethodUnQuotedStringValue)
+		l.ignore()
+		for next := l.next(); unicode.IsLetter(next) || next == '%' || next == '/'; next = l.next() {
+		}
+		l.backup()
+		if next := l.peek(); !isSpace(next) && !isEndOfLine(next) && !isEof(next) || isResourceTestingKeyword(l.input[l.start:l.pos]) {
+			l.pos = l.start
+		} else {
+			l.emit(itemInsideCheckResourceTestingUnit)
+		}
 		l.skipWhiteSpaces()
 
 		return InsideCheckResourceTesting
 	}
 
-	if strings.HasPrefix(l.input[l.pos:], "for ") {
-		l.acceptUntilSpace()
-		l.emit(itemInsideCheckProcess_ConnectionTesting_Cycle)
+	if l.emitKeyword("for", itemInsideCheckProcess_ConnectionTesting_Cycle) {
+		err := emitStringValue(l)
+		if err != nil {
+			return l.errorf("%s", err)
+		}
+		err = emitStringValue(l)
+		if err != nil {
+			return l.errorf("%s", err)
+		}
 		l.skipWhiteSpaces()
+		return InsideCheckResourceTesting
+	}
+
+	if l.emitKeyword("in last", itemInsideCheckResourceTestingPeriod) {
 		err := emitStringValue(l)
 		if err != nil {
-			return l.errorf(err.Error())
+			return l.errorf("%s", err)
 		}
 		err = emitStringValue(l)
 		if err != nil {
-			return l.errorf(err.Error())
+			return l.errorf("%s", err)
 		}
 		l.skipWhiteSpaces()
 		return InsideCheckResourceTesting
@@ -284,62 +533,105 @@ func InsideCheckResourceTesting(l *lexer) stateFn {
 	return ServiceInsideCheckProcessConnectionTesting
 }
 
+// isResourceTestingKeyword reports whether the word following a resource limit starts the rest of the test rather than being its unit.
+func isResourceTestingKeyword(word string) bool {
+	word = strings.ToLower(word)
+	return word == "" || word == "for" || word == "in" || word == "then"
+}
+
 func ServiceInsideCheckProcessConnectionTesting(l *lexer) stateFn {
-	if strings.HasPrefix(l.input[l.pos:], "unixsocket ") {
-		l.acceptUntilSpace()
-		l.emit(itemInsideCheckProcess_ConnectionTesting_UnixSocket)
-		l.skipWhiteSpaces()
+	if l.emitKeyword("unixsocket", itemInsideCheckProcess_ConnectionTesting_UnixSocket) {
 		err := emitStringValue(l)
 		if err != nil {
-			return l.errorf(err.Error())
+			return l.errorf("%s", err)
 		}
 		l.skipWhiteSpaces()
 		return ServiceInsideCheckProcessInsideConnectionTesting
 	}
 
-	if strings.HasPrefix(l.input[l.pos:], "host ") {
-		l.acceptUntilSpace()
-		l.emit(itemInsideCheckProcess_ConnectionTesting_TcpUdpHost)
+	if l.emitKeyword("host", itemInsideCheckProcess_ConnectionTesting_TcpUdpHost) {
+		err := emitStringValue(l)
+		if err != nil {
+			return l.errorf("%s", err)
+		}
 		l.skipWhiteSpaces()
+		return ServiceInsideCheckProcessConnectionTesting
+	}
+
+	if l.emitKeyword("port", itemInsideCheckProcess_ConnectionTesting_TcpUdpPort) {
 		err := emitStringValue(l)
 		if err != nil {
-			return l.errorf(err.Error())
+			return l.errorf("%s", err)
 		}
 		l.skipWhiteSpaces()
 		return ServiceInsideCheckProcessConnectionTesting
 	}
 
-	if strings.HasPrefix(l.input[l.pos:], "port ") {
-		l.acceptUntilSpace()
-		l.emit(itemInsideCheckProcess_ConnectionTesting_TcpUdpPort)
+	if l.emitKeyword("type", itemInsideCheckProcess_ConnectionTesting_TcpUdpType) {
+		err := emitStringValue(l)
+		if err != nil {
+			return l.errorf("%s", err)
+		}
 		l.skipWhiteSpaces()
+		return ServiceInsideCheckProcessConnectionTesting
+	}
+
+	if l.emitKeyword("ping", itemInsideCheckProcess_ConnectionTesting_Ping) {
+		return ServiceInsideCheckProcessConnectionTesting
+	}
+
+	if l.emitKeyword("permission", itemInsideCheckFile_Permission) || l.emitKeyword("perm", itemInsideCheckFile_Permission) {
 		err := emitStringValue(l)
 		if err != nil {
-			return l.errorf(err.Error())
+			return l.errorf("%s", err)
 		}
 		l.skipWhiteSpaces()
 		return ServiceInsideCheckProcessConnectionTesting
 	}
 
-	if strings.HasPrefix(l.input[l.pos:], "protocol ") {
-		l.acceptUntilSpace()
-		l.emit(itemInsideCheckProcess_ConnectionTesting_TcpUdpProtocol)
+	if l.emitKeyword("uid", itemInsideCheckFile_Uid) || l.emitKeyword("gid", itemInsideCheckFile_Gid) {
+		err := emitStringValue(l)
+		if err != nil {
+			return l.errorf("%s", err)
+		}
 		l.skipWhiteSpaces()
+		return ServiceInsideCheckProcessConnectionTesting
+	}
+
+	if l.emitKeyword("link", itemInsideCheckNetwork_Link) {
+		return ServiceInsideCheckProcessConnectionTesting
+	}
+
+	if l.emitKeyword("count", itemInsideCheckProcess_ConnectionTesting_Count) {
 		err := emitStringValue(l)
 		if err != nil {
-			return l.errorf(err.Error())
+			return l.errorf("%s", err)
 		}
 		l.skipWhiteSpaces()
 		return ServiceInsideCheckProcessConnectionTesting
 	}
 
-	if strings.HasPrefix(l.input[l.pos:], "then ") {
-		l.acceptUntilSpace()
-		l.emit(itemInsideCheckProcess_ConnectionTesting_Action)
+	if l.emitKeyword("protocol", itemInsideCheckProcess_ConnectionTesting_TcpUdpProtocol) {
+		err := emitStringValue(l)
+		if err != nil {
+			return l.errorf("%s", err)
+		}
 		l.skipWhiteSpaces()
+		return ServiceInsideCheckProcessConnectionTesting
+	}
+
+	if l.emitKeyword("then", itemInsideCheckProcess_ConnectionTesting_Action) {
+		action := l.pos
 		err := emitStringValue(l)
 		if err != nil {
-			return l.errorf(err.Error())
+			return l.errorf("%s", err)
+		}
+		// EXEC "<command>"
+		if _, end := scanKeyword(l.input[action:], "exec"); end > 0 && l.peek() == '"' {
+			err = emitStringValue(l)
+			if err != nil {
+				return l.errorf("%s", err)
+			}
 		}
 		l.skipWhiteSpaces()
 		return ServiceInsideCheckProcessMethods
@@ -349,42 +641,45 @@ func ServiceInsideCheckProcessConnectionTesting(l *lexer) stateFn {
 }
 
 func ServiceInsideCheckProcessInsideConnectionTesting(l *lexer) stateFn {
-	if strings.HasPrefix(l.input[l.pos:], "with timeout ") {
-		l.pos += len("with tineout")
-		l.emit(itemInsideCheckProcess_ConnectionTesting_Timeout)
-		l.skipWhiteSpaces()
+	if l.emitKeyword("with timeout", itemInsideCheckProcess_ConnectionTesting_Timeout) {
 		err := emitStringValue(l)
 		if err != nil {
-			return l.errorf(err.Error())
+			return l.errorf("%s", err)
 		}
 		err = emitStringValue(l)
 		if err != nil {
-			return l.errorf(err.Error())
+			return l.errorf("%s", err)
 		}
 		l.skipWhiteSpaces()
 
 		return ServiceInsideCheckProcessInsideConnectionTesting
 	}
 
-	if strings.HasPrefix(l.input[l.pos:], "for ") {
-		l.acceptUntilSpace()
-		l.emit(itemInsideCheckProcess_ConnectionTesting_Cycle)
-		l.skipWhiteSpaces()
+	if l.emitKeyword("for", itemInsideCheckProcess_ConnectionTesting_Cycle) {
 		err := emitStringValue(l)
 		if err != nil {
-			return l.errorf(err.Error())
+			return l.errorf("%s", err)
 		}
 		err = emitStringValue(l)
 		if err != nil {
-			return l.errorf(err.Error())
+			return l.errorf("%s", err)
 		}
 		l.skipWhiteSpaces()
 		return ServiceInsideCheckProcessInsideConnectionTesting
 	}
+
+	if !hasConnectionTestingKeyword(l) {
+		return l.errorf("unexpected text in if test")
+	}
 	l.emit(itemInsideCheckProcess_ConnectionTesting_ExitIfConditions)
 	return ServiceInsideCheckProcessConnectionTesting
 }
 
+// hasConnectionTestingKeyword reports whether the input continues with something ServiceInsideCheckProcessConnectionTesting scans.
+func hasConnectionTestingKeyword(l *lexer) bool {
+	return l.hasKeyword("unixsocket", "host", "port", "ping", "link", "permission", "perm", "uid", "gid", "count", "protocol", "type", "then")
+}
+
 /*
 Strings can be either quoted or unquoted. A quoted string is bounded by double quotes and may contain whitespace (and quoted digits are treated as a string). An unquoted string is any whitespace-delimited token, containing characters and/or numbers.
  */
@@ -401,9 +696,9 @@ func emitStringValue(l *lexer) error {
 				return nil
 			}
 		}
-	} else if isAlphaNumeric(next) {
+	} else if !isSpace(next) && !isEndOfLine(next) && !isEof(next) {
 		l.acceptUntilSpace()
-		l.emit(itemInsideCheckProcess_ProgramMethodUnQuotedStringValue)
+		l.emitWord()
 		l.skipWhiteSpaces()
 		return nil
 	}
@@ -1,10 +1,28 @@
 package lex
 
 import (
+	"context"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
 )
 
+// EqualItem matches an Item by its type and value, ignoring where it was found in the input.
+func EqualItem(expected Item) types.GomegaMatcher {
+	return WithTransform(func(item Item) Item {
+		return Item{Type: item.Type, Value: item.Value}
+	}, Equal(expected))
+}
+
+// EqualItemAt is like EqualItem, but also compares where the item is in the input.
+func EqualItemAt(expected Item) types.GomegaMatcher {
+	return WithTransform(func(item Item) Item {
+		item.lexer = nil
+		return item
+	}, Equal(expected))
+}
+
 var _ = Describe("Lex/ServiceChecks", func() {
 	var act func(string) *lexer
 
@@ -14,6 +32,7 @@ var _ = Describe("Lex/ServiceChecks", func() {
 				name:  "test",
 				input: input,
 				items: make(chan Item, 10),
+				ctx:   context.Background(),
 			}
 		}
 	})
@@ -24,17 +43,17 @@ var _ = Describe("Lex/ServiceChecks", func() {
 
 			nextLexFn := ServiceCheckStart(lex)
 			Expect(lex.pos).To(Equal(6))
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemCheckStart, Value: "check"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckStart, Value: "check"})))
 
 			Expect(nextLexFn).ToNot(BeNil())
 			nextLexFn = nextLexFn(lex)
 			Expect(lex.pos).To(Equal(14))
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemCheckProcess, Value: "process"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckProcess, Value: "process"})))
 
 			Expect(nextLexFn).ToNot(BeNil())
 			nextLexFn = nextLexFn(lex)
 			Expect(lex.pos).To(Equal(17))
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_Name, Value: "abc"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_Name, Value: "abc"})))
 
 			Expect(nextLexFn).To(BeNil())
 		})
@@ -44,21 +63,22 @@ var _ = Describe("Lex/ServiceChecks", func() {
 
 			nextLexFn := ServiceCheckStart(lex)
 			Expect(lex.pos).To(Equal(6))
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemCheckStart, Value: "check"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckStart, Value: "check"})))
 
 			Expect(nextLexFn).ToNot(BeNil())
 			nextLexFn = nextLexFn(lex)
 			Expect(lex.pos).To(Equal(14))
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemCheckProcess, Value: "process"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckProcess, Value: "process"})))
 
 			Expect(nextLexFn).ToNot(BeNil())
 			nextLexFn = nextLexFn(lex)
 			Expect(lex.pos).To(Equal(18))
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_Name, Value: "abc"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_Name, Value: "abc"})))
 
 			Expect(nextLexFn).ToNot(BeNil())
 			nextLexFn = nextLexFn(lex)
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_Pid, Value: "pidfile /tmp"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_Pid, Value: "pidfile"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "/tmp"})))
 			Expect(lex.pos).To(Equal(30))
 		})
 
@@ -68,21 +88,22 @@ var _ = Describe("Lex/ServiceChecks", func() {
 
 			nextLexFn := ServiceCheckStart(lex)
 			Expect(lex.pos).To(Equal(6))
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemCheckStart, Value: "check"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckStart, Value: "check"})))
 
 			Expect(nextLexFn).ToNot(BeNil())
 			nextLexFn = nextLexFn(lex)
 			Expect(lex.pos).To(Equal(14))
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemCheckProcess, Value: "process"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckProcess, Value: "process"})))
 
 			Expect(nextLexFn).ToNot(BeNil())
 			nextLexFn = nextLexFn(lex)
 			Expect(lex.pos).To(Equal(20))
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_Name, Value: "abc"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_Name, Value: "abc"})))
 
 			Expect(nextLexFn).ToNot(BeNil())
 			nextLexFn = nextLexFn(lex)
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_Pid, Value: "with pidfile /tmp"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_Pid, Value: "pidfile"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "/tmp"})))
 			Expect(lex.pos).To(Equal(37))
 		})
 
@@ -91,21 +112,22 @@ var _ = Describe("Lex/ServiceChecks", func() {
 
 			nextLexFn := ServiceCheckStart(lex)
 			Expect(lex.pos).To(Equal(6))
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemCheckStart, Value: "check"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckStart, Value: "check"})))
 
 			Expect(nextLexFn).ToNot(BeNil())
 			nextLexFn = nextLexFn(lex)
 			Expect(lex.pos).To(Equal(14))
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemCheckProcess, Value: "process"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckProcess, Value: "process"})))
 
 			Expect(nextLexFn).ToNot(BeNil())
 			nextLexFn = nextLexFn(lex)
 			Expect(lex.pos).To(Equal(18))
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_Name, Value: "abc"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_Name, Value: "abc"})))
 
 			Expect(nextLexFn).ToNot(BeNil())
 			nextLexFn = nextLexFn(lex)
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_Pid, Value: "matching foobar.*"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_Matching, Value: "matching"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "foobar.*"})))
 			Expect(lex.pos).To(Equal(35))
 		})
 
@@ -129,74 +151,108 @@ var _ = Describe("Lex/ServiceChecks", func() {
 
 				nextLexFn = nextLexFn(lex)
 				Expect(lex.items).To(Receive())
+				Expect(lex.items).To(Receive())
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_StartProgramMethod, Value: `start program`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_StartProgramMethod, Value: `start program`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodPath, Value: ""})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"/usr/local/mmonit/bin/mmonit"`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"/usr/local/mmonit/bin/mmonit"`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUid, Value: "uid"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"mmonit"`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUid, Value: "uid"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"mmonit"`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodGid, Value: "gid"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"mmonit"`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodGid, Value: "gid"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"mmonit"`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_StopProgramMethod, Value: `stop program`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_StopProgramMethod, Value: `stop program`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodPath, Value: ""})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"/usr/local/mmonit/bin/mmonit stop"`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"/usr/local/mmonit/bin/mmonit stop"`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUid, Value: "uid"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"stop_mmonit"`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUid, Value: "uid"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"stop_mmonit"`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodGid, Value: "gid"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"stop_mmonit"`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodGid, Value: "gid"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"stop_mmonit"`})))
 
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodGroupName, Value: "group"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "group_name"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodGroupName, Value: "group"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "group_name"})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemServiceDependencies, Value: "depends on"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "file_check"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemServiceDependencies, Value: "depends on"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "file_check"})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckResourceTesting, Value: "if total memory"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckResourceTesting, Value: "if total memory"})))
 
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckResourceTestingOperator, Value: ">"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "2048 Mb"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckResourceTestingOperator, Value: ">"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemNumber, Value: "2048"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckResourceTestingUnit, Value: "Mb"})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
 
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ConnectionTesting_Cycle, Value: "for"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `3`})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `cycles`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Cycle, Value: "for"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemNumber, Value: `3`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `cycles`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ConnectionTesting_Action, Value: "then"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `alert`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Action, Value: "then"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `alert`})))
+			})
+		})
+
+		Context("With dependencies", func() {
+			It("should scan every service of a comma separated dependency list", func() {
+				lex := act(`check process abc
+  pidfile /tmp
+  depends on first, second ,third
+  group group_name`)
+
+				nextLexFn := ServiceCheckStart(lex)
+				Expect(lex.items).To(Receive())
+
+				nextLexFn = nextLexFn(lex)
+				Expect(lex.items).To(Receive())
+
+				nextLexFn = nextLexFn(lex)
+				Expect(lex.items).To(Receive())
+
+				nextLexFn = nextLexFn(lex)
+				Expect(lex.items).To(Receive())
+				Expect(lex.items).To(Receive())
+
+				Expect(nextLexFn).ToNot(BeNil())
+				nextLexFn = nextLexFn(lex)
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemServiceDependencies, Value: "depends on"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "first"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "second"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "third"})))
+
+				Expect(nextLexFn).ToNot(BeNil())
+				nextLexFn = nextLexFn(lex)
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodGroupName, Value: "group"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "group_name"})))
 			})
 		})
 
@@ -232,36 +288,37 @@ or:
 
 				nextLexFn = nextLexFn(lex)
 				Expect(lex.items).To(Receive())
+				Expect(lex.items).To(Receive())
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ConnectionTestingEnterIfConditions, Value: "if failed"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTestingEnterIfConditions, Value: "if failed"})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ConnectionTesting_UnixSocket, Value: "unixsocket"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `/path/to/socket.sock`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_UnixSocket, Value: "unixsocket"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `/path/to/socket.sock`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ConnectionTesting_Timeout, Value: "with timeout"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `5`})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `seconds`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Timeout, Value: "with timeout"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemNumber, Value: `5`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `seconds`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ConnectionTesting_Cycle, Value: "for"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `5`})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `cycles`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Cycle, Value: "for"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemNumber, Value: `5`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `cycles`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ConnectionTesting_ExitIfConditions, Value: ""})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_ExitIfConditions, Value: ""})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ConnectionTesting_Action, Value: "then"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `restart`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Action, Value: "then"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `restart`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
@@ -284,51 +341,52 @@ or:
 
 				nextLexFn = nextLexFn(lex)
 				Expect(lex.items).To(Receive())
+				Expect(lex.items).To(Receive())
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ConnectionTestingEnterIfConditions, Value: "if failed"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTestingEnterIfConditions, Value: "if failed"})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ConnectionTesting_TcpUdpHost, Value: "host"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `1.2.3.4`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_TcpUdpHost, Value: "host"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `1.2.3.4`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ConnectionTesting_TcpUdpPort, Value: "port"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `9876`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_TcpUdpPort, Value: "port"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemNumber, Value: `9876`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ConnectionTesting_TcpUdpProtocol, Value: "protocol"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `http`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_TcpUdpProtocol, Value: "protocol"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `http`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
 
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ConnectionTesting_Timeout, Value: "with timeout"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `20`})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `seconds`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Timeout, Value: "with timeout"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemNumber, Value: `20`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `seconds`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
 				Expect(nextLexFn).ToNot(BeNil())
 
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ConnectionTesting_Cycle, Value: "for"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `10`})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `cycles`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Cycle, Value: "for"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemNumber, Value: `10`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `cycles`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ConnectionTesting_ExitIfConditions, Value: ""})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_ExitIfConditions, Value: ""})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ConnectionTesting_Action, Value: "then"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `stop`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Action, Value: "then"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: `stop`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
@@ -343,21 +401,25 @@ or:
 
 			nextLexFn := ServiceCheckStart(lex)
 			Expect(lex.pos).To(Equal(6))
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemCheckStart, Value: "check"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckStart, Value: "check"})))
 
 			Expect(nextLexFn).ToNot(BeNil())
 			nextLexFn = nextLexFn(lex)
 			Expect(lex.pos).To(Equal(11))
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemCheckFile, Value: "file"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckFile, Value: "file"})))
 
 			Expect(nextLexFn).ToNot(BeNil())
 			nextLexFn = nextLexFn(lex)
 			Expect(lex.pos).To(Equal(23))
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckFile_Name, Value: "unique-name"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckFile_Name, Value: "unique-name"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckFile_Path, Value: "path"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "/tmp/test"})))
 
 			Expect(nextLexFn).ToNot(BeNil())
 			nextLexFn = nextLexFn(lex)
-			Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckFile_Path, Value: "/tmp/test"})))
 			Expect(nextLexFn).To(BeNil())
 		})
 
@@ -387,45 +449,251 @@ or:
 				Expect(nextLexFn).ToNot(BeNil())
 				Expect(lex.items).To(Receive(&nextItem))
 				Expect(nextItem.Type).To(Equal(itemInsideCheckFile_Path))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "/tmp/test"})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_StartProgramMethod, Value: `start program`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_StartProgramMethod, Value: `start program`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodPath, Value: ""})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"/usr/local/mmonit/bin/mmonit"`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"/usr/local/mmonit/bin/mmonit"`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUid, Value: "uid"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"mmonit"`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUid, Value: "uid"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"mmonit"`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodGid, Value: "gid"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"mmonit"`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodGid, Value: "gid"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"mmonit"`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_StopProgramMethod, Value: `stop program`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_StopProgramMethod, Value: `stop program`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodPath, Value: ""})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"/usr/local/mmonit/bin/mmonit stop"`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"/usr/local/mmonit/bin/mmonit stop"`})))
 
 				Expect(nextLexFn).ToNot(BeNil())
 				nextLexFn = nextLexFn(lex)
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodUid, Value: "uid"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"stop_mmonit"`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUid, Value: "uid"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"stop_mmonit"`})))
 
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodGid, Value: "gid"})))
-				Expect(lex.items).To(Receive(Equal(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"stop_mmonit"`})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodGid, Value: "gid"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodQuotedStringValue, Value: `"stop_mmonit"`})))
 
 				Expect(nextLexFn).To(BeNil())
 			})
 		})
+
+		Context("With if changed testing", func() {
+			It("should scan check file with if changed test", func() {
+				lex := act(`check file unique-name
+  path /tmp/test
+  if changed checksum then alert`)
+
+				nextLexFn := ServiceCheckStart(lex)
+				Expect(lex.items).To(Receive())
+
+				nextLexFn = nextLexFn(lex)
+				Expect(lex.items).To(Receive())
+
+				nextLexFn = nextLexFn(lex)
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckFile_Name, Value: "unique-name"})))
+
+				Expect(nextLexFn).ToNot(BeNil())
+				nextLexFn = nextLexFn(lex)
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckFile_Path, Value: "path"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "/tmp/test"})))
+
+				Expect(nextLexFn).ToNot(BeNil())
+				nextLexFn = nextLexFn(lex)
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckFile_IfChanged, Value: "if changed"})))
+
+				Expect(nextLexFn).ToNot(BeNil())
+				nextLexFn = nextLexFn(lex)
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "checksum"})))
+
+				Expect(nextLexFn).ToNot(BeNil())
+				nextLexFn = nextLexFn(lex)
+				Expect(nextLexFn).ToNot(BeNil())
+				nextLexFn = nextLexFn(lex)
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Action, Value: "then"})))
+				Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "alert"})))
+
+				Expect(nextLexFn).ToNot(BeNil())
+				nextLexFn = nextLexFn(lex)
+				Expect(nextLexFn).To(BeNil())
+			})
+		})
+	})
+
+	Context("Check Host", func() {
+		It("Should scan check host with address and ping test", func() {
+			lex := act(`check host upstream with address 10.0.0.1
+  if failed ping count 3 then alert`)
+
+			nextLexFn := ServiceCheckStart(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckStart, Value: "check"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckHost, Value: "host"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckHost_Name, Value: "upstream"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckHost_Address, Value: "address"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "10.0.0.1"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTestingEnterIfConditions, Value: "if failed"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Ping, Value: "ping"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Count, Value: "count"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemNumber, Value: "3"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Action, Value: "then"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "alert"})))
+		})
+	})
+
+	Context("Check System", func() {
+		It("Should scan check system with a qualified resource test", func() {
+			lex := act(`check system $HOST
+  if loadavg (5min) > 2.5 for 3 cycles then alert
+  if uptime < 3 days then alert`)
+
+			nextLexFn := ServiceCheckStart(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckStart, Value: "check"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckSystem, Value: "system"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckSystem_Name, Value: "$HOST"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckResourceTesting_LoadAvg, Value: "if loadavg"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckResourceTestingQualifier, Value: "5min"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckResourceTestingOperator, Value: ">"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemNumber, Value: "2.5"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Cycle, Value: "for"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemNumber, Value: "3"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "cycles"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Action, Value: "then"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "alert"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckResourceTesting_Uptime, Value: "if uptime"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckResourceTestingOperator, Value: "<"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemNumber, Value: "3"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckResourceTestingUnit, Value: "days"})))
+		})
+	})
+
+	Context("Check Filesystem", func() {
+		It("Should scan check filesystem with path and permission test", func() {
+			lex := act(`check filesystem rootfs with path /
+  if failed permission 0660 then unmonitor`)
+
+			nextLexFn := ServiceCheckStart(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckStart, Value: "check"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckFilesystem, Value: "filesystem"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckFilesystem_Name, Value: "rootfs"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckFile_Path, Value: "path"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "/"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTestingEnterIfConditions, Value: "if failed"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckFile_Permission, Value: "permission"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemNumber, Value: "0660"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ConnectionTesting_Action, Value: "then"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "unmonitor"})))
+		})
+	})
+
+	Context("Comments", func() {
+		It("Should scan comments before and after a statement", func() {
+			lex := act(`# web server
+  #   restarted by hand
+check process nginx # the proxy`)
+
+			nextLexFn := ServiceCheckStart(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemComment, Value: "# web server"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemComment, Value: "#   restarted by hand"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckStart, Value: "check"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn = nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemCheckProcess, Value: "process"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_Name, Value: "nginx"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemComment, Value: "# the proxy"})))
+		})
+
+		It("Should end a pidfile at a trailing comment", func() {
+			lex := act(`pidfile /var/run/nginx.pid # written by nginx
+  start program = "/bin/start"`)
+
+			nextLexFn := ServiceInsideCheckProcessPid(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_Pid, Value: "pidfile"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "/var/run/nginx.pid"})))
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemComment, Value: "# written by nginx"})))
+
+			Expect(nextLexFn).ToNot(BeNil())
+			nextLexFn(lex)
+			Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_StartProgramMethod, Value: "start program"})))
+		})
 	})
 })
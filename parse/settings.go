@@ -0,0 +1,285 @@
+package lex
+
+import (
+	"strings"
+	"unicode"
+)
+
+// settings are the global statements following "set".
+var settings = []struct {
+	keyword  string
+	item     itemType
+	hasValue bool
+}{
+	{"daemon", itemSet_Daemon, true},
+	{"logfile", itemSet_Logfile, true},
+	{"pidfile", itemSet_Pidfile, true},
+	{"idfile", itemSet_Idfile, true},
+	{"statefile", itemSet_Statefile, true},
+	{"limits", itemSet_Limits, false},
+	{"onreboot", itemSet_OnReboot, true},
+	{"httpd", itemSet_HTTPD, false},
+	{"mailserver", itemSet_Mailserver, true},
+	{"alert", itemSet_Alert, true},
+	{"mmonit", itemSet_MMonit, true},
+	{"eventqueue", itemSet_EventQueue, false},
+}
+
+// setClauses are the clauses continuing a set statement, longest keyword first.
+// Noise keywords such as the "with" of "with timeout" need no entries of their own, see scanKeyword.
+var setClauses = []struct {
+	keyword  string
+	item     itemType
+	hasValue bool
+}{
+	{"using", itemSet_Using, true},
+	{"start delay", itemSet_StartDelay, true},
+	{"facility", itemSet_Facility, true},
+	{"port", itemSet_Port, true},
+	{"address", itemSetHTTPD_Address, true},
+	{"allow", itemSetHTTPD_Allow, true},
+	{"read-only", itemSetHTTPD_ReadOnly, false},
+	{"ssl enable", itemSetHTTPD_SSL, false},
+	{"ssl", itemSetHTTPD_SSL, false},
+	{"pemfile", itemSetHTTPD_PemFile, true},
+	{"username", itemSet_Username, true},
+	{"password", itemSet_Password, true},
+	{"timeout", itemSet_Timeout, true},
+	{"reminder on", itemSet_Reminder, true},
+	{"register without credentials", itemSet_RegisterWithoutCredentials, false},
+	{"basedir", itemSet_Basedir, true},
+	{"slots", itemSet_Slots, true},
+}
+
+// setNoiseKeywords may follow a value of a set statement without changing its meaning.
+var setNoiseKeywords = []string{"and", "cycles", "cycle", "seconds", "second"}
+
+// alertEventFilters start the event list of an alert, longest keyword first.
+var alertEventFilters = []struct {
+	keyword string
+	item    itemType
+}{
+	{"but not on", itemSet_NotOn},
+	{"not on", itemSet_NotOn},
+	{"only on", itemSet_On},
+	{"on", itemSet_On},
+}
+
+// mailFormatOptions start an option of a mail-format block.
+var mailFormatOptions = []string{"from:", "reply-to:", "subject:", "message:"}
+
+/*
+SET <DAEMON | LOGFILE | PIDFILE | IDFILE | STATEFILE | LIMITS | ONREBOOT | HTTPD | MAILSERVER | ALERT | MAIL-FORMAT | MMONIT | EVENTQUEUE> ...
+ */
+func SetStart(l *lexer) stateFn {
+	l.emitKeyword("set", itemSetStart)
+
+	if l.emitKeyword("mail-format", itemSet_MailFormat) {
+		return InsideSetMailFormat
+	}
+
+	for _, setting := range settings {
+		if l.emitKeyword(setting.keyword, setting.item) {
+			if !setting.hasValue {
+				return InsideSetStatement
+			}
+			err := emitSetValue(l)
+			if err != nil {
+				return l.errorf("%s", err)
+			}
+			return InsideSetStatement
+		}
+	}
+	return l.errorf("unsupported set statement")
+}
+
+/*
+The optional clauses of a set statement, such as WITH START DELAY <number>, ALLOW <user:password> or NOT ON { <event>, ... }.
+The alert statements of a check share these clauses, so scanning resumes with the statements of the check once they end.
+ */
+func InsideSetStatement(l *lexer) stateFn {
+	l.skipWhiteSpaces()
+
+	for _, noise := range setNoiseKeywords {
+		if l.acceptKeyword(noise) {
+			l.ignore()
+			return InsideSetStatement
+		}
+	}
+	if l.accept("{") {
+		l.emit(itemBlockStart)
+		return InsideSetBlock
+	}
+	if l.accept(",") {
+		l.emit(itemComma)
+		l.skipWhiteSpaces()
+		err := emitSetValue(l)
+		if err != nil {
+			return l.errorf("%s", err)
+		}
+		return InsideSetStatement
+	}
+
+	for _, clause := range setClauses {
+		if l.emitKeyword(clause.keyword, clause.item) {
+			if !clause.hasValue {
+				return InsideSetStatement
+			}
+			err := emitSetValue(l)
+			if err != nil {
+				return l.errorf("%s", err)
+			}
+			return InsideSetStatement
+		}
+	}
+	for _, filter := range alertEventFilters {
+		if l.emitKeyword(filter.keyword, filter.item) {
+			return InsideSetEventList
+		}
+	}
+	if l.emitKeyword("with mail-format", itemSet_MailFormat) {
+		return InsideSetMailFormat
+	}
+	if l.inCheck {
+		return ServiceInsideCheckProcessMethods
+	}
+	return ServiceCheckStart
+}
+
+/*
+{ <name>: <value> [unit] [, ...] }
+ */
+func InsideSetBlock(l *lexer) stateFn {
+	l.skipWhiteSpaces()
+	if l.accept("}") {
+		l.emit(itemBlockEnd)
+		return InsideSetStatement
+	}
+
+	for next := l.next(); unicode.IsLetter(next); next = l.next() {
+	}
+	l.backup()
+	if l.pos == l.start {
+		return l.errorf("set block missing '}'")
+	}
+	l.emit(itemSet_OptionName)
+
+	l.skipWhiteSpaces()
+	if !l.accept(":") {
+		return l.errorf("set block expects '<name>: <value>'")
+	}
+	l.ignore()
+	l.skipWhiteSpaces()
+
+	for next := l.next(); next != ',' && next != '}' && !isEndOfLine(next) && !isEof(next); next = l.next() {
+	}
+	l.backup()
+	l.emit(itemInsideCheckProcess_ProgramMethodUnQuotedStringValue)
+	if l.accept(",") {
+		l.ignore()
+	}
+	return InsideSetBlock
+}
+
+/*
+{ <event> [, ...] }
+ */
+func InsideSetEventList(l *lexer) stateFn {
+	if !l.accept("{") {
+		return l.errorf("event list expects '{'")
+	}
+	l.emit(itemBlockStart)
+
+	for {
+		l.skipWhiteSpaces()
+		if l.accept("}") {
+			l.emit(itemBlockEnd)
+			return InsideSetStatement
+		}
+		if l.accept(",") {
+			l.ignore()
+			continue
+		}
+
+		for next := l.next(); isAlphaNumeric(next); next = l.next() {
+		}
+		l.backup()
+		if l.pos == l.start {
+			return l.errorf("event list missing '}'")
+		}
+		l.emit(itemInsideCheckProcess_ProgramMethodUnQuotedStringValue)
+	}
+}
+
+/*
+{ <FROM | REPLY-TO | SUBJECT | MESSAGE>: <text> ... }, where the text runs to the end of the line and a message may continue over the following lines
+ */
+func InsideSetMailFormat(l *lexer) stateFn {
+	if !l.accept("{") {
+		return l.errorf("mail-format expects '{'")
+	}
+	l.emit(itemBlockStart)
+	return InsideSetMailFormatOption
+}
+
+func InsideSetMailFormatOption(l *lexer) stateFn {
+	l.skipWhiteSpaces()
+	if l.accept("}") {
+		l.emit(itemBlockEnd)
+		return InsideSetStatement
+	}
+	if !hasMailFormatOption(l.input[l.pos:]) {
+		return l.errorf("mail-format expects one of from:, reply-to:, subject: or message: before '}'")
+	}
+
+	l.pos += strings.Index(l.input[l.pos:], ":")
+	l.emit(itemSet_OptionName)
+	l.next()
+	l.ignore()
+	l.acceptRun(" \t")
+	l.ignore()
+
+	for {
+		for next := l.next(); !isEndOfLine(next) && !isEof(next); next = l.next() {
+		}
+		l.backup()
+
+		// A block closed at the end of its last line, e.g. "{ from: monit@example.com }".
+		if line := strings.TrimRight(l.input[l.start:l.pos], spaceChars); strings.HasSuffix(line, "}") {
+			l.pos = l.start + len(line) - len("}")
+			break
+		}
+
+		nextLine := strings.TrimLeft(l.input[l.pos:], spaceChars)
+		if nextLine == "" || strings.HasPrefix(nextLine, "}") || hasMailFormatOption(nextLine) {
+			break
+		}
+		l.pos = len(l.input) - len(nextLine)
+	}
+	l.emit(itemInsideCheckProcess_ProgramMethodUnQuotedStringValue)
+	return InsideSetMailFormatOption
+}
+
+func hasMailFormatOption(input string) bool {
+	for _, option := range mailFormatOptions {
+		if hasKeywordPrefix(input, option) {
+			return true
+		}
+	}
+	return false
+}
+
+// emitSetValue emits the quoted or unquoted value of a set statement, which ends at a comma separating it from the next one.
+func emitSetValue(l *lexer) error {
+	if l.peek() == '"' {
+		return emitStringValue(l)
+	}
+
+	l.acceptUntilSpaceOrComma()
+	if l.pos > l.start {
+		l.emitWord()
+	}
+	l.acceptRun(" \t")
+	l.ignore()
+	return nil
+}
@@ -0,0 +1,90 @@
+package lex
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lex/Settings", func() {
+	var act func(string) *lexer
+
+	BeforeEach(func() {
+		act = func(input string) *lexer {
+			return &lexer{
+				name:  "settings",
+				input: input,
+				items: make(chan Item, 10),
+				ctx:   context.Background(),
+			}
+		}
+	})
+
+	It("Should scan set daemon with start delay", func() {
+		lex := act(`set daemon 30 with start delay 240`)
+
+		nextLexFn := ServiceCheckStart(lex)
+		Expect(nextLexFn).ToNot(BeNil())
+		nextLexFn = nextLexFn(lex)
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemSetStart, Value: "set"})))
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemSet_Daemon, Value: "daemon"})))
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemNumber, Value: "30"})))
+
+		Expect(nextLexFn).ToNot(BeNil())
+		nextLexFn = nextLexFn(lex)
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemSet_StartDelay, Value: "start delay"})))
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemNumber, Value: "240"})))
+	})
+
+	It("Should scan set limits block", func() {
+		lex := act(`set limits {
+  programOutput: 512 B,
+  networkTimeout: 5 seconds
+}`)
+
+		nextLexFn := ServiceCheckStart(lex)
+		nextLexFn = nextLexFn(lex)
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemSetStart, Value: "set"})))
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemSet_Limits, Value: "limits"})))
+
+		nextLexFn = nextLexFn(lex)
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemBlockStart, Value: "{"})))
+
+		nextLexFn = nextLexFn(lex)
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemSet_OptionName, Value: "programOutput"})))
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "512 B"})))
+
+		nextLexFn = nextLexFn(lex)
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemSet_OptionName, Value: "networkTimeout"})))
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "5 seconds"})))
+
+		nextLexFn = nextLexFn(lex)
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemBlockEnd, Value: "}"})))
+		Expect(nextLexFn).ToNot(BeNil())
+	})
+
+	It("Should scan set alert with an event list and reminder", func() {
+		lex := act(`set alert oncall@example.com not on { instance, action } with reminder on 10 cycles`)
+
+		nextLexFn := ServiceCheckStart(lex)
+		nextLexFn = nextLexFn(lex)
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemSetStart, Value: "set"})))
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemSet_Alert, Value: "alert"})))
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "oncall@example.com"})))
+
+		nextLexFn = nextLexFn(lex)
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemSet_NotOn, Value: "not on"})))
+
+		nextLexFn = nextLexFn(lex)
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemBlockStart, Value: "{"})))
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "instance"})))
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemInsideCheckProcess_ProgramMethodUnQuotedStringValue, Value: "action"})))
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemBlockEnd, Value: "}"})))
+
+		nextLexFn = nextLexFn(lex)
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemSet_Reminder, Value: "reminder on"})))
+		Expect(lex.items).To(Receive(EqualItem(Item{Type: itemNumber, Value: "10"})))
+		Expect(nextLexFn).ToNot(BeNil())
+	})
+})